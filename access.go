@@ -0,0 +1,52 @@
+package trie
+
+import "sort"
+
+// WithAccessCounting counts the Get and GetPath hits of each value in the
+// path trie, so TopKeys can report the most accessed keys, such as for
+// cache tuning. Counting costs an extra map entry per accessed value, and
+// makes Get modify the trie, so concurrent Gets must be synchronized by the
+// caller. Replacing a value keeps its count, while deleting it resets it.
+func WithAccessCounting[T any]() PathTrieOption[T] {
+	return func(trie *pathTrie[T]) {
+		trie.config.accessCounts = map[*pathTrie[T]]uint64{}
+	}
+}
+
+// countAccess counts a Get hit of the node value, if counting.
+func (trie *pathTrie[T]) countAccess(node *pathTrie[T]) {
+	if trie.config.accessCounts != nil {
+		trie.config.accessCounts[node]++
+	}
+}
+
+// TopKeys returns up to n keys with the most Get hits, most accessed first.
+// Keys with equal counts are sorted by key and keys which were never
+// accessed are omitted. Returns nil if the trie was not created
+// WithAccessCounting.
+func (trie *pathTrie[T]) TopKeys(n int) []string {
+	if trie.config.accessCounts == nil || n <= 0 {
+		return nil
+	}
+	type entry struct {
+		key   string
+		count uint64
+	}
+	entries := make([]entry, 0, len(trie.config.accessCounts))
+	trie.walkNodes("", func(key string, node *pathTrie[T]) {
+		if count := trie.config.accessCounts[node]; count > 0 {
+			entries = append(entries, entry{key: key, count: count})
+		}
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].key < entries[j].key
+	})
+	keys := make([]string, 0, minInt(n, len(entries)))
+	for _, e := range entries[:minInt(n, len(entries))] {
+		keys = append(keys, e.key)
+	}
+	return keys
+}
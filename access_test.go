@@ -0,0 +1,49 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathTrieWithAccessCounting(t *testing.T) {
+	trie := NewPathTrie(WithAccessCounting[int]())
+	for i, key := range []string{"/cat", "/cat/gideon", "/dog", "/fish", "/bird"} {
+		trie.Put(key, i)
+	}
+	if keys := trie.TopKeys(3); len(keys) != 0 {
+		t.Errorf("expected no accessed keys, got %v", keys)
+	}
+
+	gets := map[string]int{"/cat": 2, "/cat/gideon": 5, "/dog": 2, "/bird": 1, "/missing": 3}
+	for key, n := range gets {
+		for i := 0; i < n; i++ {
+			trie.Get(key)
+		}
+	}
+	trie.GetPath([]string{"/bird"})
+	trie.GetPath([]string{"/bird"})
+
+	cases := map[int][]string{
+		0:  nil,
+		1:  {"/cat/gideon"},
+		3:  {"/cat/gideon", "/bird", "/cat"}, // ties sorted by key
+		10: {"/cat/gideon", "/bird", "/cat", "/dog"},
+	}
+	for n, expected := range cases {
+		if keys := trie.TopKeys(n); !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected top %d keys %v, got %v", n, expected, keys)
+		}
+	}
+
+	// deleting resets counts
+	trie.Delete("/cat/gideon")
+	trie.Put("/cat/gideon", 10)
+	trie.Get("/cat/gideon")
+	if keys := trie.TopKeys(2); !reflect.DeepEqual(keys, []string{"/bird", "/cat"}) {
+		t.Errorf("expected top 2 keys [/bird /cat], got %v", keys)
+	}
+
+	if keys := NewPathTrie[int]().TopKeys(1); keys != nil {
+		t.Errorf("expected nil without access counting, got %v", keys)
+	}
+}
@@ -2,6 +2,8 @@ package trie
 
 import (
 	"crypto/rand"
+	"fmt"
+	"sort"
 	"testing"
 )
 
@@ -135,6 +137,210 @@ func BenchmarkPathTrieGetPathKey(b *testing.B) {
 	}
 }
 
+func BenchmarkPathTrieDeleteAbsentPathKey(b *testing.B) {
+	trie := NewPathTrie[int]()
+	for i := 0; i < len(pathKeys); i++ {
+		trie.Put(pathKeys[i], i)
+	}
+	var absentKeys [len(pathKeys)]string // descend existing nodes, then miss
+	for i := 0; i < len(pathKeys); i++ {
+		absentKeys[i] = pathKeys[i] + "/absent"
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		trie.Delete(absentKeys[i%len(absentKeys)])
+	}
+}
+
+// delete/reinsert churn
+
+func BenchmarkPathTrieDeleteChurn(b *testing.B) {
+	benchmarkPathTrieDeleteChurn(b, NewPathTrie[int]())
+}
+
+func BenchmarkPathTrieDeleteChurnWithoutCleanup(b *testing.B) {
+	benchmarkPathTrieDeleteChurn(b, NewPathTrie(WithoutDeleteCleanup[int]()))
+}
+
+func benchmarkPathTrieDeleteChurn(b *testing.B, trie PathTrie[int]) {
+	for i := 0; i < len(pathKeys); i++ {
+		trie.Put(pathKeys[i], i)
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		key := pathKeys[i%len(pathKeys)]
+		trie.Delete(key)
+		trie.Put(key, i)
+	}
+}
+
+// batched deletes
+
+func BenchmarkPathTrieDeleteLoop(b *testing.B) {
+	trie := NewPathTrie[int]()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for j := 0; j < len(pathKeys); j++ {
+			trie.Put(pathKeys[j], j)
+		}
+		b.StartTimer()
+		for _, key := range pathKeys {
+			trie.Delete(key)
+		}
+	}
+}
+
+func BenchmarkPathTrieDeleteAll(b *testing.B) {
+	trie := NewPathTrie[int]()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for j := 0; j < len(pathKeys); j++ {
+			trie.Put(pathKeys[j], j)
+		}
+		b.StartTimer()
+		trie.DeleteAll(pathKeys[:])
+	}
+}
+
+// sorted bulk loads
+
+func BenchmarkPathTriePutSortedPathKeys(b *testing.B) {
+	keys := sortedPathKeys()
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		trie := NewPathTrie[int]()
+		for j, key := range keys {
+			trie.Put(key, j)
+		}
+	}
+}
+
+func BenchmarkSortedBuilderPathKeys(b *testing.B) {
+	keys := sortedPathKeys()
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		builder := NewSortedBuilder[int]()
+		for j, key := range keys {
+			builder.Add(key, j)
+		}
+		builder.Build()
+	}
+}
+
+// sortedPathKeys returns sorted path keys which share prefixes, as in a
+// file listing.
+func sortedPathKeys() []string {
+	var keys []string
+	for _, a := range pathKeys[:10] {
+		for _, b := range pathKeys[:10] {
+			for _, c := range pathKeys[:10] {
+				keys = append(keys, a+b+c)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// child stores
+
+func BenchmarkPathTrieGetPathKeyMapChildren(b *testing.B) {
+	benchmarkPathTrieGetPathKey(b, NewPathTrie[int]())
+}
+
+func BenchmarkPathTrieGetPathKeySliceChildren(b *testing.B) {
+	benchmarkPathTrieGetPathKey(b, NewPathTrie(withChildStore(newSliceChildren[int])))
+}
+
+func BenchmarkPathTrieGetPathKeyAdaptiveChildren(b *testing.B) {
+	benchmarkPathTrieGetPathKey(b, NewPathTrie(WithAdaptiveChildren[int](0)))
+}
+
+func BenchmarkPathTrieBuildPathKeysMapChildren(b *testing.B) {
+	benchmarkPathTrieBuildPathKeys(b)
+}
+
+func BenchmarkPathTrieBuildPathKeysAdaptiveChildren(b *testing.B) {
+	benchmarkPathTrieBuildPathKeys(b, WithAdaptiveChildren[int](0))
+}
+
+func benchmarkPathTrieBuildPathKeys(b *testing.B, opts ...PathTrieOption[int]) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		trie := NewPathTrie(opts...)
+		for j, key := range pathKeys {
+			trie.Put(key, j)
+		}
+	}
+}
+
+func benchmarkPathTrieGetPathKey(b *testing.B, trie PathTrie[int]) {
+	for i := 0; i < len(pathKeys); i++ {
+		trie.Put(pathKeys[i], i)
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		trie.Get(pathKeys[i%len(pathKeys)])
+	}
+}
+
+// recursive and iterative walks
+
+func BenchmarkPathTrieWalkShallowWide(b *testing.B) {
+	benchmarkPathTrieWalk(b, NewPathTrie[int](), shallowWideKeys())
+}
+
+func BenchmarkPathTrieWalkShallowWideIterative(b *testing.B) {
+	benchmarkPathTrieWalk(b, NewPathTrie(WithIterativeWalk[int]()), shallowWideKeys())
+}
+
+func BenchmarkPathTrieWalkDeepNarrow(b *testing.B) {
+	benchmarkPathTrieWalk(b, NewPathTrie[int](), deepNarrowKeys())
+}
+
+func BenchmarkPathTrieWalkDeepNarrowIterative(b *testing.B) {
+	benchmarkPathTrieWalk(b, NewPathTrie(WithIterativeWalk[int]()), deepNarrowKeys())
+}
+
+func benchmarkPathTrieWalk(b *testing.B, trie PathTrie[int], keys []string) {
+	for i, key := range keys {
+		trie.Put(key, i)
+	}
+	walker := func(key string, value int) error { return nil }
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		trie.Walk(walker)
+	}
+}
+
+// shallowWideKeys returns keys which are all children of the root.
+func shallowWideKeys() []string {
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("/k%d", i)
+	}
+	return keys
+}
+
+// deepNarrowKeys returns keys along a single chain of segments.
+func deepNarrowKeys() []string {
+	keys := make([]string, 1000)
+	key := ""
+	for i := range keys {
+		key += "/k"
+		keys[i] = key
+	}
+	return keys
+}
+
 // benchmark PathSegmenter
 
 func BenchmarkPathSegmenter(b *testing.B) {
@@ -0,0 +1,80 @@
+package trie
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrNoValueCodec is returned when marshaling or unmarshaling a path trie
+// which has no value codec. See WithValueCodec.
+var ErrNoValueCodec = errors.New("trie: no value codec")
+
+// WithValueCodec sets the functions used to encode and decode values when
+// marshaling the path trie to and from its binary form.
+func WithValueCodec[T any](encode func(T) []byte, decode func([]byte) (T, error)) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) {
+		trie.config.encodeValue = encode
+		trie.config.decodeValue = decode
+	}
+}
+
+// MarshalBinary encodes the key/value pairs in the trie. Each key and each
+// encoded value is written as a uvarint length followed by its bytes.
+// Returns ErrNoValueCodec if the trie has no value codec.
+func (trie *pathTrie[T]) MarshalBinary() ([]byte, error) {
+	if trie.config.encodeValue == nil {
+		return nil, ErrNoValueCodec
+	}
+	var data []byte
+	trie.Walk(func(key string, value T) error {
+		data = binary.AppendUvarint(data, uint64(len(key)))
+		data = append(data, key...)
+		encoded := trie.config.encodeValue(value)
+		data = binary.AppendUvarint(data, uint64(len(encoded)))
+		data = append(data, encoded...)
+		return nil
+	})
+	return data, nil
+}
+
+// UnmarshalBinary replaces the contents of the trie with the key/value pairs
+// decoded from data produced by MarshalBinary. Returns ErrNoValueCodec if the
+// trie has no value codec or io.ErrUnexpectedEOF if data is truncated.
+func (trie *pathTrie[T]) UnmarshalBinary(data []byte) error {
+	if trie.config.decodeValue == nil {
+		return ErrNoValueCodec
+	}
+	trie.clearChildren()
+	trie.clearValue()
+	for len(data) > 0 {
+		key, rest, err := readBinaryField(data)
+		if err != nil {
+			return err
+		}
+		encoded, rest, err := readBinaryField(rest)
+		if err != nil {
+			return err
+		}
+		value, err := trie.config.decodeValue(encoded)
+		if err != nil {
+			return err
+		}
+		if err := trie.PutChecked(string(key), value); err != nil {
+			return err
+		}
+		data = rest
+	}
+	return nil
+}
+
+// readBinaryField reads a uvarint length prefixed field from data and
+// returns the field and the remaining data.
+func readBinaryField(data []byte) (field []byte, rest []byte, err error) {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || n > uint64(len(data)-size) {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	end := size + int(n)
+	return data[size:end], data[end:], nil
+}
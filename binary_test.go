@@ -0,0 +1,86 @@
+package trie
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func stringCodec() PathTrieOption[string] {
+	encode := func(value string) []byte { return []byte(value) }
+	decode := func(data []byte) (string, error) { return string(data), nil }
+	return WithValueCodec(encode, decode)
+}
+
+func TestPathTrieBinaryRoundTrip(t *testing.T) {
+	table := map[string]string{
+		"":                 "root",
+		"fish":             "nemo",
+		"/cat":             "gideon",
+		"/cat/kitten":      "",
+		"/notes/new":       "new note",
+		"/notes/new/noise": "這是第三個值",
+	}
+	trie := NewPathTrie(stringCodec())
+	for key, value := range table {
+		trie.Put(key, value)
+	}
+
+	data, err := trie.MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	jsonData, err := json.Marshal(table)
+	if err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	if len(data) >= len(jsonData) {
+		t.Errorf("expected binary size %d to be smaller than JSON size %d", len(data), len(jsonData))
+	}
+
+	decoded := NewPathTrie(stringCodec())
+	decoded.Put("/stale", "replaced by unmarshal")
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	walked := make(map[string]string)
+	decoded.Walk(func(key string, value string) error {
+		walked[key] = value
+		return nil
+	})
+	if len(walked) != len(table) {
+		t.Errorf("expected %d keys after round trip, got %d", len(table), len(walked))
+	}
+	for key, value := range table {
+		if walked[key] != value {
+			t.Errorf("expected key %s to have value %q, got %q", key, value, walked[key])
+		}
+	}
+}
+
+func TestPathTrieBinaryErrors(t *testing.T) {
+	trie := NewPathTrie[string]()
+	if _, err := trie.MarshalBinary(); err != ErrNoValueCodec {
+		t.Errorf("expected ErrNoValueCodec, got %v", err)
+	}
+	if err := trie.UnmarshalBinary(nil); err != ErrNoValueCodec {
+		t.Errorf("expected ErrNoValueCodec, got %v", err)
+	}
+
+	trie = NewPathTrie(stringCodec())
+	trie.Put("/cat", "gideon")
+	data, _ := trie.MarshalBinary()
+	if err := trie.UnmarshalBinary(data[:len(data)-1]); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	decodeError := errors.New("decode error")
+	trie = NewPathTrie(WithValueCodec(
+		func(value string) []byte { return []byte(value) },
+		func(data []byte) (string, error) { return "", decodeError },
+	))
+	if err := trie.UnmarshalBinary(data); err != decodeError {
+		t.Errorf("expected decode error, got %v", err)
+	}
+}
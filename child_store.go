@@ -0,0 +1,307 @@
+package trie
+
+import (
+	"iter"
+	"sort"
+)
+
+// childStore holds the children of a path trie node by segment. The default
+// store is a map; alternative stores may trade memory for lookup speed for
+// tries with few or short segments. Stores are only allocated for nodes with
+// children. Users supply stores with WithChildStore.
+type childStore[T any] interface {
+	// get returns the child for the segment, or nil if there is none.
+	get(part string) *pathTrie[T]
+	// set stores the child for the segment, replacing any existing child.
+	set(part string, child *pathTrie[T])
+	// remove removes the child for the segment, if any.
+	remove(part string)
+	// len returns the number of children.
+	len() int
+	// all iterates over the segments and children in no particular order.
+	// Iteration must tolerate removing the current segment.
+	all() iter.Seq2[string, *pathTrie[T]]
+}
+
+// mapChildren is the default childStore.
+type mapChildren[T any] map[string]*pathTrie[T]
+
+func newMapChildren[T any]() childStore[T] {
+	return mapChildren[T]{}
+}
+
+func (m mapChildren[T]) get(part string) *pathTrie[T] {
+	return m[part]
+}
+
+func (m mapChildren[T]) set(part string, child *pathTrie[T]) {
+	m[part] = child
+}
+
+func (m mapChildren[T]) remove(part string) {
+	delete(m, part)
+}
+
+func (m mapChildren[T]) len() int {
+	return len(m)
+}
+
+func (m mapChildren[T]) all() iter.Seq2[string, *pathTrie[T]] {
+	return func(yield func(string, *pathTrie[T]) bool) {
+		for part, child := range m {
+			if !yield(part, child) {
+				return
+			}
+		}
+	}
+}
+
+// sliceChildren is a childStore of children sorted by segment, which is
+// smaller than a map and as fast to search for few children.
+type sliceChildren[T any] struct {
+	parts    []string
+	children []*pathTrie[T]
+}
+
+func newSliceChildren[T any]() childStore[T] {
+	return &sliceChildren[T]{}
+}
+
+func (s *sliceChildren[T]) get(part string) *pathTrie[T] {
+	if i := sort.SearchStrings(s.parts, part); i < len(s.parts) && s.parts[i] == part {
+		return s.children[i]
+	}
+	return nil
+}
+
+func (s *sliceChildren[T]) set(part string, child *pathTrie[T]) {
+	i := sort.SearchStrings(s.parts, part)
+	if i < len(s.parts) && s.parts[i] == part {
+		s.children[i] = child
+		return
+	}
+	s.parts = append(s.parts, "")
+	copy(s.parts[i+1:], s.parts[i:])
+	s.parts[i] = part
+	s.children = append(s.children, nil)
+	copy(s.children[i+1:], s.children[i:])
+	s.children[i] = child
+}
+
+func (s *sliceChildren[T]) remove(part string) {
+	if i := sort.SearchStrings(s.parts, part); i < len(s.parts) && s.parts[i] == part {
+		s.parts = append(s.parts[:i], s.parts[i+1:]...)
+		s.children = append(s.children[:i], s.children[i+1:]...)
+	}
+}
+
+func (s *sliceChildren[T]) len() int {
+	return len(s.parts)
+}
+
+func (s *sliceChildren[T]) all() iter.Seq2[string, *pathTrie[T]] {
+	return func(yield func(string, *pathTrie[T]) bool) {
+		// iterate over copies so the current segment may be removed
+		parts := append([]string(nil), s.parts...)
+		children := append([]*pathTrie[T](nil), s.children...)
+		for i, part := range parts {
+			if !yield(part, children[i]) {
+				return
+			}
+		}
+	}
+}
+
+// adaptiveChildrenThreshold is the default number of children above which
+// an adaptiveChildren store switches from a slice to a map.
+const adaptiveChildrenThreshold = 8
+
+// adaptiveChildren is a childStore which holds children in a sliceChildren
+// store while there are few and in a map once there are more than the
+// threshold, in the manner of adaptive radix trees. It switches back to a
+// slice once removals leave half the threshold or fewer children, so
+// adding and removing a child at the threshold does not convert the store
+// each time.
+type adaptiveChildren[T any] struct {
+	threshold int
+	small     *sliceChildren[T] // nil once switched to large
+	large     mapChildren[T]
+}
+
+func newAdaptiveChildren[T any](threshold int) func() childStore[T] {
+	return func() childStore[T] {
+		return &adaptiveChildren[T]{threshold: threshold, small: &sliceChildren[T]{}}
+	}
+}
+
+func (a *adaptiveChildren[T]) get(part string) *pathTrie[T] {
+	if a.small != nil {
+		return a.small.get(part)
+	}
+	return a.large[part]
+}
+
+func (a *adaptiveChildren[T]) set(part string, child *pathTrie[T]) {
+	if a.small == nil {
+		a.large[part] = child
+		return
+	}
+	if a.small.len() < a.threshold || a.small.get(part) != nil {
+		a.small.set(part, child)
+		return
+	}
+	a.large = make(mapChildren[T], a.small.len()+1)
+	for i, part := range a.small.parts {
+		a.large[part] = a.small.children[i]
+	}
+	a.large[part] = child
+	a.small = nil
+}
+
+func (a *adaptiveChildren[T]) remove(part string) {
+	if a.small != nil {
+		a.small.remove(part)
+		return
+	}
+	delete(a.large, part)
+	if len(a.large) > a.threshold/2 {
+		return
+	}
+	a.small = &sliceChildren[T]{}
+	for part, child := range a.large {
+		a.small.set(part, child)
+	}
+	a.large = nil
+}
+
+func (a *adaptiveChildren[T]) len() int {
+	if a.small != nil {
+		return a.small.len()
+	}
+	return len(a.large)
+}
+
+func (a *adaptiveChildren[T]) all() iter.Seq2[string, *pathTrie[T]] {
+	if a.small != nil {
+		return a.small.all()
+	}
+	return a.large.all()
+}
+
+// WithAdaptiveChildren stores the children of each path trie node in a
+// sorted slice while the node has up to threshold children, and in a map
+// once it has more, for the memory efficiency of slices at small fan-out and
+// the speed of maps at large fan-out. Nodes switch back to a slice once
+// deletes leave half the threshold or fewer children. A threshold of 0 or
+// less uses a threshold of 8.
+func WithAdaptiveChildren[T any](threshold int) PathTrieOption[T] {
+	if threshold <= 0 {
+		threshold = adaptiveChildrenThreshold
+	}
+	return withChildStore(newAdaptiveChildren[T](threshold))
+}
+
+// withChildStore sets the function which allocates the childStore of each
+// node with children. The default is a map.
+func withChildStore[T any](newStore func() childStore[T]) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.newChildren = newStore }
+}
+
+// ChildStore holds the children of a path trie node by segment, such as a
+// sorted slice which uses less memory than a map for nodes with few
+// children. Children are of an opaque type C, such as PathTrieChild, which
+// stores hold and return without inspecting. Stores are only allocated for
+// nodes with children and are not used concurrently.
+type ChildStore[C any] interface {
+	// Get returns the child for the segment, if any.
+	Get(segment string) (child C, ok bool)
+	// Set stores the child for the segment, replacing any existing child.
+	Set(segment string, child C)
+	// Delete removes the child for the segment, if any.
+	Delete(segment string)
+	// Len returns the number of children.
+	Len() int
+	// All iterates over the segments and children in any order. Iteration
+	// must tolerate deleting the current segment.
+	All() iter.Seq2[string, C]
+}
+
+// PathTrieChild is an opaque child node of a path trie, held by a
+// ChildStore.
+type PathTrieChild[T any] struct {
+	node *pathTrie[T]
+}
+
+// WithChildStore sets the function which allocates the ChildStore holding
+// the children of each path trie node. The default store is a map.
+func WithChildStore[T any](newStore func() ChildStore[PathTrieChild[T]]) PathTrieOption[T] {
+	return withChildStore(func() childStore[T] {
+		return customChildren[T]{store: newStore()}
+	})
+}
+
+// customChildren is a childStore adapting a ChildStore set by
+// WithChildStore.
+type customChildren[T any] struct {
+	store ChildStore[PathTrieChild[T]]
+}
+
+func (c customChildren[T]) get(part string) *pathTrie[T] {
+	child, _ := c.store.Get(part)
+	return child.node
+}
+
+func (c customChildren[T]) set(part string, child *pathTrie[T]) {
+	c.store.Set(part, PathTrieChild[T]{node: child})
+}
+
+func (c customChildren[T]) remove(part string) {
+	c.store.Delete(part)
+}
+
+func (c customChildren[T]) len() int {
+	return c.store.Len()
+}
+
+func (c customChildren[T]) all() iter.Seq2[string, *pathTrie[T]] {
+	return func(yield func(string, *pathTrie[T]) bool) {
+		for part, child := range c.store.All() {
+			if !yield(part, child.node) {
+				return
+			}
+		}
+	}
+}
+
+// child returns the child for the segment, or nil if there is none.
+func (trie *pathTrie[T]) child(part string) *pathTrie[T] {
+	if trie.children == nil {
+		return nil
+	}
+	return trie.children.get(part)
+}
+
+// numChildren returns the number of children of the node.
+func (trie *pathTrie[T]) numChildren() int {
+	if trie.children == nil {
+		return 0
+	}
+	return trie.children.len()
+}
+
+// childNodes iterates over the segments and children of the node.
+func (trie *pathTrie[T]) childNodes() iter.Seq2[string, *pathTrie[T]] {
+	if trie.children == nil {
+		return func(func(string, *pathTrie[T]) bool) {}
+	}
+	return trie.children.all()
+}
+
+// removeChild removes the child for the segment, releasing the childStore
+// once the node has no children.
+func (trie *pathTrie[T]) removeChild(part string) {
+	trie.children.remove(part)
+	if trie.children.len() == 0 {
+		trie.children = nil
+	}
+}
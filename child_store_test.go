@@ -0,0 +1,162 @@
+package trie
+
+import (
+	"iter"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// sortedChildStore is a ChildStore of children sorted by segment, written
+// against the exported API only, as a user would.
+type sortedChildStore[C any] struct {
+	segments []string
+	children []C
+}
+
+func newSortedChildStore[C any]() ChildStore[C] {
+	return &sortedChildStore[C]{}
+}
+
+func (s *sortedChildStore[C]) Get(segment string) (C, bool) {
+	if i, ok := slices.BinarySearch(s.segments, segment); ok {
+		return s.children[i], true
+	}
+	var zero C
+	return zero, false
+}
+
+func (s *sortedChildStore[C]) Set(segment string, child C) {
+	i, ok := slices.BinarySearch(s.segments, segment)
+	if ok {
+		s.children[i] = child
+		return
+	}
+	s.segments = slices.Insert(s.segments, i, segment)
+	s.children = slices.Insert(s.children, i, child)
+}
+
+func (s *sortedChildStore[C]) Delete(segment string) {
+	if i, ok := slices.BinarySearch(s.segments, segment); ok {
+		s.segments = slices.Delete(s.segments, i, i+1)
+		s.children = slices.Delete(s.children, i, i+1)
+	}
+}
+
+func (s *sortedChildStore[C]) Len() int {
+	return len(s.segments)
+}
+
+func (s *sortedChildStore[C]) All() iter.Seq2[string, C] {
+	return func(yield func(string, C) bool) {
+		// iterate over copies so the current segment may be deleted
+		segments, children := slices.Clone(s.segments), slices.Clone(s.children)
+		for i, segment := range segments {
+			if !yield(segment, children[i]) {
+				return
+			}
+		}
+	}
+}
+
+func TestPathTrieWithChildStore(t *testing.T) {
+	newStore := newSortedChildStore[PathTrieChild[any]]
+	trie := NewPathTrie(WithChildStore(newStore))
+	testTrie(t, trie)
+
+	trie = NewPathTrie(WithChildStore(newStore))
+	testTrieWalkRange(t, trie, nil)
+
+	// the store holds the children
+	trie = NewPathTrie(WithChildStore(newStore))
+	for _, key := range []string{"/c", "/a", "/b/x"} {
+		trie.Put(key, key)
+	}
+	store := trie.(*pathTrie[any]).children.(customChildren[any]).store.(*sortedChildStore[PathTrieChild[any]])
+	if !sort.StringsAreSorted(store.segments) || len(store.segments) != 3 {
+		t.Errorf("expected store to hold 3 sorted segments, got %v", store.segments)
+	}
+
+	trie = NewPathTrie(WithChildStore(newStore), WithoutDeleteCleanup[any]())
+	for _, key := range []string{"/a/b", "/a/c", "/d"} {
+		trie.Put(key, key)
+	}
+	trie.Delete("/a/b")
+	trie.Delete("/a/c")
+	if pruned := trie.Prune(); pruned != 3 {
+		t.Errorf("expected 3 nodes pruned, got %d", pruned)
+	}
+	if keys := trie.ChildrenKeys(""); len(keys) != 1 || keys[0] != "/d" {
+		t.Errorf("expected only child /d to remain, got %v", keys)
+	}
+}
+
+func TestPathTrieWithAdaptiveChildren(t *testing.T) {
+	trie := NewPathTrie(WithAdaptiveChildren[any](0))
+	testTrie(t, trie)
+
+	trie = NewPathTrie(WithAdaptiveChildren[any](2))
+	testTrieWalkRange(t, trie, nil)
+
+	paths := NewPathTrie(WithAdaptiveChildren[int](4))
+	root := paths.(*pathTrie[int])
+	isSlice := func() bool {
+		return root.children.(*adaptiveChildren[int]).small != nil
+	}
+	keys := []string{"/a", "/b", "/c", "/d", "/e", "/f"}
+	checkKeys := func(n int) {
+		t.Helper()
+		for i, key := range keys {
+			value, ok := paths.Get(key)
+			if i < n && (!ok || value != i) {
+				t.Errorf("expected %s to hold %d, got (%d, %t)", key, i, value, ok)
+			} else if i >= n && ok {
+				t.Errorf("expected %s to be absent, got %d", key, value)
+			}
+		}
+	}
+
+	// up to the threshold children are held in a slice
+	for i, key := range keys[:4] {
+		paths.Put(key, i)
+	}
+	paths.Put("/b/x", 1)
+	if !isSlice() {
+		t.Errorf("expected 4 children in a slice")
+	}
+	checkKeys(4)
+
+	// more children switch to a map
+	for i, key := range keys[4:] {
+		paths.Put(key, i+4)
+	}
+	if isSlice() {
+		t.Errorf("expected 6 children in a map")
+	}
+	checkKeys(6)
+	// replacing a child at the threshold does not switch back
+	paths.Put("/a", 0)
+	if isSlice() {
+		t.Errorf("expected replaced child to stay in a map")
+	}
+
+	// removing down to half the threshold switches back to a slice
+	for i := 5; i >= 3; i-- {
+		paths.Delete(keys[i])
+	}
+	if isSlice() {
+		t.Errorf("expected 3 children in a map")
+	}
+	paths.Delete(keys[2])
+	if !isSlice() {
+		t.Errorf("expected 2 children in a slice")
+	}
+	checkKeys(2)
+	if value, ok := paths.Get("/b/x"); !ok || value != 1 {
+		t.Errorf("expected /b/x to hold 1, got (%d, %t)", value, ok)
+	}
+	paths.DeleteAll([]string{"/a", "/b", "/b/x"})
+	if !paths.IsEmpty() || root.children != nil {
+		t.Errorf("expected empty trie to release its children")
+	}
+}
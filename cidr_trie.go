@@ -0,0 +1,77 @@
+package trie
+
+import "net/netip"
+
+// CIDRTrie maps IP networks to values for longest prefix matching, as in a
+// routing table. IPv4 and IPv6 networks are stored in separate binary tries
+// and IPv4-mapped IPv6 addresses match IPv4 networks.
+type CIDRTrie[T any] struct {
+	v4 bitNode[T]
+	v6 bitNode[T]
+}
+
+// NewCIDRTrie allocates and returns a new CIDRTrie.
+func NewCIDRTrie[T any]() *CIDRTrie[T] {
+	return &CIDRTrie[T]{}
+}
+
+// root returns the binary trie and key bytes for the address.
+func (t *CIDRTrie[T]) root(addr netip.Addr) (*bitNode[T], []byte) {
+	if addr.Is4() {
+		key := addr.As4()
+		return &t.v4, key[:]
+	}
+	key := addr.As16()
+	return &t.v6, key[:]
+}
+
+// parsePrefix parses a CIDR network (e.g. "10.0.0.0/8"), unmapping
+// IPv4-mapped IPv6 networks.
+func parsePrefix(cidr string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return prefix, err
+	}
+	if addr := prefix.Addr(); addr.Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(addr.Unmap(), prefix.Bits()-96)
+	}
+	return prefix, nil
+}
+
+// Put inserts the value into the trie for the given CIDR network (e.g.
+// "10.0.0.0/8" or "2001:db8::/32"), replacing any existing value. Host bits
+// of the network address are ignored. Returns an error if the network
+// cannot be parsed.
+func (t *CIDRTrie[T]) Put(cidr string, value T) error {
+	prefix, err := parsePrefix(cidr)
+	if err != nil {
+		return err
+	}
+	root, key := t.root(prefix.Addr())
+	root.put(key, prefix.Bits(), value)
+	return nil
+}
+
+// Delete removes the value associated with the given CIDR network. Returns
+// true if a value was removed.
+func (t *CIDRTrie[T]) Delete(cidr string) bool {
+	prefix, err := parsePrefix(cidr)
+	if err != nil {
+		return false
+	}
+	root, key := t.root(prefix.Addr())
+	return root.delete(key, prefix.Bits(), 0)
+}
+
+// Match returns the value of the most specific network containing the given
+// IP address. Returns false if no network contains the address or the
+// address cannot be parsed.
+func (t *CIDRTrie[T]) Match(ip string) (T, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return zeroValueOfT[T](), false
+	}
+	root, key := t.root(addr.Unmap())
+	_, value, ok := root.longestPrefix(key, len(key)*8)
+	return value, ok
+}
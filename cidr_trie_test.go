@@ -0,0 +1,60 @@
+package trie
+
+import "testing"
+
+func TestCIDRTrie(t *testing.T) {
+	trie := NewCIDRTrie[string]()
+	for cidr, value := range map[string]string{
+		"0.0.0.0/0":          "default",
+		"10.0.0.0/8":         "10/8",
+		"10.1.0.0/16":        "10.1/16",
+		"10.1.2.0/24":        "10.1.2/24",
+		"10.1.2.3/32":        "host",
+		"192.168.1.77/24":    "lan", // host bits are ignored
+		"2001:db8::/32":      "doc",
+		"2001:db8:abcd::/48": "doc-site",
+	} {
+		if err := trie.Put(cidr, value); err != nil {
+			t.Errorf("expected error nil, got %v", err)
+		}
+	}
+	for _, cidr := range []string{"10.0.0.0", "10.0.0.0/33", "bogus/8"} {
+		if err := trie.Put(cidr, "invalid"); err == nil {
+			t.Errorf("expected error for invalid network %s", cidr)
+		}
+	}
+
+	// longest prefix wins
+	cases := map[string]string{
+		"10.1.2.3":           "host",
+		"10.1.2.4":           "10.1.2/24",
+		"10.1.3.1":           "10.1/16",
+		"10.200.0.1":         "10/8",
+		"11.0.0.1":           "default",
+		"192.168.1.1":        "lan",
+		"::ffff:10.1.2.3":    "host",
+		"2001:db8::1":        "doc",
+		"2001:db8:abcd:1::1": "doc-site",
+	}
+	for ip, expected := range cases {
+		if value, ok := trie.Match(ip); !ok || value != expected {
+			t.Errorf("expected ip %s to match %s, got %s", ip, expected, value)
+		}
+	}
+	// IPv6 addresses do not match IPv4 networks
+	for _, ip := range []string{"2001:db9::1", "::1", "not-an-ip"} {
+		if value, ok := trie.Match(ip); ok {
+			t.Errorf("expected ip %s to not match, got %s", ip, value)
+		}
+	}
+
+	if !trie.Delete("10.1.2.0/24") {
+		t.Error("expected network 10.1.2.0/24 to be deleted")
+	}
+	if trie.Delete("10.1.2.0/24") || trie.Delete("bogus") {
+		t.Error("expected missing networks to not be deleted")
+	}
+	if value, _ := trie.Match("10.1.2.4"); value != "10.1/16" {
+		t.Errorf("expected ip 10.1.2.4 to match 10.1/16, got %s", value)
+	}
+}
@@ -0,0 +1,29 @@
+package trie
+
+// PutReportingCollision inserts the value into the trie at the given key,
+// like Put, and reports whether a different key already held a value at the
+// same node, along with that key. Keys collide when a lossy segmenter, such
+// as one which folds case, maps distinct keys to the same segments. Keys are
+// compared after normalization by WithKeyNormalizer, so keys it maps
+// together do not collide.
+//
+// Once called, the trie records the key of each value Put. The keys of
+// values Put before the first call are unknown, so replacing them reports
+// no collision. If the value fails validation it is not inserted and no
+// collision is reported.
+func (trie *pathTrie[T]) PutReportingCollision(key string, value T) (collidedWith string, collided bool) {
+	key = trie.normalizeKey(key)
+	if trie.validate(key, value) != nil {
+		return "", false
+	}
+	if trie.config.putKeys == nil {
+		trie.config.putKeys = map[*pathTrie[T]]string{}
+	}
+	if node := trie.nodeAt(key); node != nil && node.value != nil {
+		if existing, ok := trie.config.putKeys[node]; ok && existing != key {
+			collidedWith, collided = existing, true
+		}
+	}
+	trie.put(key, &value)
+	return collidedWith, collided
+}
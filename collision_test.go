@@ -0,0 +1,67 @@
+package trie
+
+import (
+	"strings"
+	"testing"
+)
+
+// foldingSegmenter segments paths like PathSegmenter, folding case.
+func foldingSegmenter(path string, start int) (string, int) {
+	segment, next := PathSegmenter(path, start)
+	return strings.ToLower(segment), next
+}
+
+func TestPathTriePutReportingCollision(t *testing.T) {
+	trie := NewPathTrie(WithSegmenter[int](foldingSegmenter))
+	cases := []struct {
+		key          string
+		collidedWith string
+		collided     bool
+	}{
+		{"/Users/Alice", "", false},
+		{"/users/bob", "", false},
+		// same key again replaces without colliding
+		{"/Users/Alice", "", false},
+		{"/users/alice", "/Users/Alice", true},
+		// reports the latest key put at the node
+		{"/USERS/ALICE", "/users/alice", true},
+		{"/Users/Bob", "/users/bob", true},
+		{"/users", "", false},
+	}
+	for i, c := range cases {
+		collidedWith, collided := trie.PutReportingCollision(c.key, i)
+		if collidedWith != c.collidedWith || collided != c.collided {
+			t.Errorf("PutReportingCollision(%q): expected (%q, %t), got (%q, %t)", c.key, c.collidedWith, c.collided, collidedWith, collided)
+		}
+		if value, _ := trie.Get(c.key); value != i {
+			t.Errorf("expected %q to hold %d, got %d", c.key, i, value)
+		}
+	}
+
+	// deleted keys no longer collide
+	trie.Delete("/users/bob")
+	if collidedWith, collided := trie.PutReportingCollision("/USERS/BOB", 0); collided {
+		t.Errorf("expected no collision after delete, got %q", collidedWith)
+	}
+
+	// keys Put before the first call are unknown and do not collide
+	trie = NewPathTrie(WithSegmenter[int](foldingSegmenter))
+	trie.Put("/A", 1)
+	if collidedWith, collided := trie.PutReportingCollision("/A", 2); collided {
+		t.Errorf("expected no collision for the same key, got %q", collidedWith)
+	}
+	trie.Put("/Users/Dave", 1)
+	if collidedWith, collided := trie.PutReportingCollision("/users/dave", 2); !collided || collidedWith != "/Users/Dave" {
+		t.Errorf("expected collision with %q, got (%q, %t)", "/Users/Dave", collidedWith, collided)
+	}
+	if collidedWith, collided := trie.PutReportingCollision("/users/dave", 3); collided {
+		t.Errorf("expected no collision for the same key, got %q", collidedWith)
+	}
+
+	// keys mapped together by a normalizer do not collide
+	trie = NewPathTrie(WithSegmenter[int](foldingSegmenter), WithKeyNormalizer[int](strings.ToLower))
+	trie.PutReportingCollision("/A", 1)
+	if collidedWith, collided := trie.PutReportingCollision("/a", 2); collided {
+		t.Errorf("expected no collision for normalized keys, got %q", collidedWith)
+	}
+}
@@ -1,13 +1,63 @@
 package trie
 
 import (
+	"bufio"
+	"errors"
+	"io"
+	"math/rand/v2"
 	"strings"
+	"time"
 )
 
 // WalkFunc defines some action to take on the given key and value during
 // a Trie Walk. Returning a non-nil error will terminate the Walk.
 type WalkFunc[T any] func(key string, value T) error
 
+// ChainWalkers returns a WalkFunc which calls each of the walkers in order
+// with the key and value, stopping at the first walker to return an error
+// and returning that error.
+func ChainWalkers[T any](walkers ...WalkFunc[T]) WalkFunc[T] {
+	return func(key string, value T) error {
+		for _, walker := range walkers {
+			if err := walker(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SegmentsWalkFunc defines some action to take on the given key segments and
+// value during a PathTrie WalkSegments. Returning a non-nil error will
+// terminate the WalkSegments.
+type SegmentsWalkFunc[T any] func(segments []string, value T) error
+
+// WalkMetrics reports the cost of a walk. Depths count segments for path
+// tries and runes for rune tries, with the root at depth 0.
+type WalkMetrics struct {
+	NodesVisited    int // nodes visited, including internal nodes
+	ValuesDelivered int // key/values passed to the walker
+	MaxDepth        int // depth of the deepest node visited
+}
+
+// KeyValue is a key and its value.
+type KeyValue[T any] struct {
+	Key   string
+	Value T
+}
+
+// GroupedWalkFunc defines some action to take on the children of the node
+// at parentKey during a Trie WalkGrouped. Returning a non-nil error will
+// terminate the WalkGrouped.
+type GroupedWalkFunc[T any] func(parentKey string, children []KeyValue[T]) error
+
+// TreeWalkFunc defines some action to take on the given node during a Trie
+// WalkTree. The value is the zero value for nodes without a value. isLast
+// reports whether the node is the last child of its parent and depth is the
+// depth of the node, with the root at depth 0. Returning a non-nil error
+// will terminate the WalkTree.
+type TreeWalkFunc[T any] func(key string, value T, isLast bool, depth int) error
+
 // StringSegmenter takes a string key with a starting index and returns
 // the first segment after the start and the ending index. When the end is
 // reached, the returned nextIndex should be -1.
@@ -29,9 +79,134 @@ func PathSegmenter(path string, start int) (segment string, next int) {
 	return path[start : start+end+1], start + end + 1
 }
 
+// CommonDepth returns the number of leading segments, as split by the
+// segmenter, which keys a and b share, such as to tell whether one key is
+// an ancestor of the other without a trie.
+func CommonDepth(segmenter StringSegmenter, a, b string) int {
+	var depth int
+	partA, i := segmenter(a, 0)
+	partB, j := segmenter(b, 0)
+	for partA != "" && partA == partB {
+		depth++
+		partA, i = segmenter(a, i)
+		partB, j = segmenter(b, j)
+	}
+	return depth
+}
+
+// sample returns a key/value chosen uniformly at random from the trie by
+// reservoir sampling over a single Walk.
+func sample[T any](trie Trie[T], rng *rand.Rand) (string, T, bool) {
+	var key string
+	var value T
+	var count int
+	trie.Walk(func(k string, v T) error {
+		count++
+		// replace the choice with probability 1/count
+		if rng.IntN(count) == 0 {
+			key, value = k, v
+		}
+		return nil
+	})
+	return key, value, count > 0
+}
+
+// loadLines reads r line by line and puts each entry parsed from a line into
+// the trie. Lines may be arbitrarily long and may end in "\n" or "\r\n".
+// Returns the number of entries put and any read error.
+func loadLines[T any](trie Trie[T], r io.Reader, parse func(line string) (key string, value T, ok bool)) (int, error) {
+	reader := bufio.NewReader(r)
+	var count int
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			if key, value, ok := parse(line); ok {
+				trie.Put(key, value)
+				count++
+			}
+		}
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+}
+
+// ErrWalkDeadline is returned by WalkDeadline when the deadline passes
+// before the walk completes.
+var ErrWalkDeadline = errors.New("trie: walk deadline exceeded")
+
+// deadlineCheckInterval is the number of values walked between checks of a
+// walk deadline, to limit the overhead of reading the clock.
+const deadlineCheckInterval = 64
+
+// walkDeadline walks with the given walk function, aborting with
+// ErrWalkDeadline once the deadline has passed.
+func walkDeadline[T any](walk func(WalkFunc[T]) error, deadline time.Time, walker WalkFunc[T]) error {
+	if !time.Now().Before(deadline) {
+		return ErrWalkDeadline
+	}
+	var count int
+	return walk(func(key string, value T) error {
+		if count++; count%deadlineCheckInterval == 0 && !time.Now().Before(deadline) {
+			return ErrWalkDeadline
+		}
+		return walker(key, value)
+	})
+}
+
+// errWalkLimit stops a walk once the limit of values is reached.
+var errWalkLimit = errors.New("walk limit reached")
+
+// walkLimit walks with the given walk function, calling walker for at most
+// limit values.
+func walkLimit[T any](walk func(WalkFunc[T]) error, limit int, walker WalkFunc[T]) error {
+	if limit <= 0 {
+		return nil
+	}
+	var count int
+	err := walk(func(key string, value T) error {
+		if err := walker(key, value); err != nil {
+			return err
+		}
+		if count++; count == limit {
+			return errWalkLimit
+		}
+		return nil
+	})
+	if err == errWalkLimit {
+		return nil
+	}
+	return err
+}
+
+// walkCollectErrors walks with the given walk function, collecting every
+// error the walker returns rather than stopping at the first.
+func walkCollectErrors[T any](walk func(WalkFunc[T]) error, walker WalkFunc[T]) []error {
+	var errs []error
+	walk(func(key string, value T) error {
+		if err := walker(key, value); err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	})
+	return errs
+}
+
 // zeroValueOfT returns the zero value of type T. For example, the
 // empty string ("") for string, 0 for int, nil for pointers, etc.
 func zeroValueOfT[T any]() T {
 	var t T
 	return t
 }
+
+// minInt returns the smaller of a and b.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,60 @@
+package trie
+
+// WithAutoCompact makes Delete on a trie created WithoutDeleteCleanup call
+// Prune once more than threshold nodes have been emptied by Deletes since
+// the last Prune. A node is emptied when Delete leaves it in place where
+// Delete would otherwise have removed it. This bounds the memory held by
+// emptied nodes while keeping most of the allocation savings.
+func WithAutoCompact[T any](threshold int) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) {
+		trie.config.autoCompact = true
+		trie.config.compactThreshold = threshold
+	}
+}
+
+// compactEmptied counts the nodes emptied by deleting the value of the leaf
+// node at the end of the path, and prunes the trie if too many nodes have
+// been emptied.
+func (trie *pathTrie[T]) compactEmptied(path []nodeStr[T]) {
+	if len(path) == 0 || trie.isTombstone() {
+		// root or tombstone nodes are not emptied
+		return
+	}
+	emptied := 1
+	// path[0] is the root, which is never emptied
+	for i := len(path) - 1; i > 0; i-- {
+		parent := path[i].node
+		if parent.numChildren() > 1 || parent.value != nil || parent.isTombstone() {
+			break
+		}
+		emptied++
+	}
+	trie.config.emptiedNodes += emptied
+	if trie.config.emptiedNodes > trie.config.compactThreshold {
+		path[0].node.Prune()
+	}
+}
+
+// WastedNodes returns the number of nodes which hold no value or tombstone
+// and have no such descendants, which Prune would remove.
+func (trie *pathTrie[T]) WastedNodes() int {
+	count, _ := trie.wastedNodes()
+	return count
+}
+
+// wastedNodes returns the number of wasted descendant nodes and whether the
+// subtree holds a value or tombstone.
+func (trie *pathTrie[T]) wastedNodes() (int, bool) {
+	var count int
+	live := trie.value != nil || trie.isTombstone()
+	for _, child := range trie.childNodes() {
+		childCount, childLive := child.wastedNodes()
+		count += childCount
+		if childLive {
+			live = true
+		} else {
+			count++
+		}
+	}
+	return count, live
+}
@@ -0,0 +1,65 @@
+package trie
+
+import "sync"
+
+// Number is a constraint for the integer and floating point types a
+// CounterTrie counts with.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// CounterTrie is a path trie of numeric counters, such as request counts
+// keyed by path. CounterTrie is safe for concurrent use.
+type CounterTrie[N Number] struct {
+	mu   sync.Mutex
+	trie *pathTrie[N]
+}
+
+// NewCounterTrie allocates and returns a new CounterTrie.
+func NewCounterTrie[N Number]() *CounterTrie[N] {
+	return &CounterTrie[N]{trie: NewPathTrie[N]().(*pathTrie[N])}
+}
+
+// Increment atomically adds delta to the counter at the given key, starting
+// missing counters from zero, and returns the new total.
+func (t *CounterTrie[N]) Increment(key string, delta N) N {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	total := delta
+	if value, ok := t.trie.Get(key); ok {
+		total += value
+	}
+	t.trie.Put(key, total)
+	return total
+}
+
+// Get returns the counter at the given key. Returns false if the key has
+// never been incremented or was deleted.
+func (t *CounterTrie[N]) Get(key string) (N, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.trie.Get(key)
+}
+
+// Delete removes the counter at the given key. Returns true if a counter
+// was removed.
+func (t *CounterTrie[N]) Delete(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.trie.Get(key); !ok {
+		return false
+	}
+	return t.trie.Delete(key)
+}
+
+// Walk iterates over each counter and calls the given walker function with
+// the key and total. If the walker function returns an error, the walk is
+// aborted. The walker must not call methods on the CounterTrie.
+// The traversal is depth first with no guaranteed order.
+func (t *CounterTrie[N]) Walk(walker WalkFunc[N]) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.trie.Walk(walker)
+}
@@ -0,0 +1,64 @@
+package trie
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCounterTrie(t *testing.T) {
+	counters := NewCounterTrie[int]()
+	if total := counters.Increment("/api/users", 2); total != 2 {
+		t.Errorf("expected total 2, got %d", total)
+	}
+	if total := counters.Increment("/api/users", 3); total != 5 {
+		t.Errorf("expected total 5, got %d", total)
+	}
+	if total := counters.Increment("/api", -1); total != -1 {
+		t.Errorf("expected total -1, got %d", total)
+	}
+	if total, ok := counters.Get("/api/users"); !ok || total != 5 {
+		t.Errorf("expected key /api/users to have total 5, got %d", total)
+	}
+	if _, ok := counters.Get("/api/posts"); ok {
+		t.Error("expected key /api/posts to be missing")
+	}
+	walked := make(map[string]int)
+	counters.Walk(func(key string, total int) error {
+		walked[key] = total
+		return nil
+	})
+	if expected := map[string]int{"/api": -1, "/api/users": 5}; !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected totals %v, got %v", expected, walked)
+	}
+	if !counters.Delete("/api/users") || counters.Delete("/api/users") {
+		t.Error("expected key /api/users to be deleted once")
+	}
+	if total := counters.Increment("/api/users", 1); total != 1 {
+		t.Errorf("expected deleted counter to restart, got total %d", total)
+	}
+}
+
+func TestCounterTrieConcurrentIncrement(t *testing.T) {
+	counters := NewCounterTrie[float64]()
+	const goroutines = 50
+	const increments = 100
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				counters.Increment("/hits", 0.5)
+				counters.Increment("/hits/home", 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if total, _ := counters.Get("/hits"); total != goroutines*increments*0.5 {
+		t.Errorf("expected total %v, got %v", goroutines*increments*0.5, total)
+	}
+	if total, _ := counters.Get("/hits/home"); total != goroutines*increments {
+		t.Errorf("expected total %d, got %v", goroutines*increments, total)
+	}
+}
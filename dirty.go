@@ -0,0 +1,53 @@
+package trie
+
+// WithDirtyTracking marks the value of a key dirty whenever it is put into
+// the path trie, by Put, PutChecked, PutPath, PutRef, or a loaded value,
+// for lightweight change tracking such as incremental syncs. Use WalkDirty
+// to visit the dirty key/values and ClearDirty once they are synced.
+// Tracking costs an extra map entry per dirty value.
+func WithDirtyTracking[T any]() PathTrieOption[T] {
+	return func(trie *pathTrie[T]) {
+		trie.config.dirty = map[*pathTrie[T]]struct{}{}
+	}
+}
+
+// WalkDirty calls the walker for each key/value put since the trie was
+// created or ClearDirty was last called. Deleted keys are not visited, nor
+// values modified in place (e.g. by WalkMutate). Visits nothing if the trie
+// was not created WithDirtyTracking. Returns the first error returned by
+// the walker.
+// The order of key/values is unspecified, as with Walk.
+func (trie *pathTrie[T]) WalkDirty(walker WalkFunc[T]) error {
+	if len(trie.config.dirty) == 0 {
+		return nil
+	}
+	// collect dirty nodes before calling the walker so a walker which puts
+	// or deletes keys does not modify the nodes being walked
+	type entry struct {
+		key  string
+		node *pathTrie[T]
+	}
+	var entries []entry
+	trie.walkNodes("", func(key string, node *pathTrie[T]) {
+		if _, ok := trie.config.dirty[node]; ok {
+			entries = append(entries, entry{key: key, node: node})
+		}
+	})
+	for _, e := range entries {
+		if e.node.value == nil {
+			continue
+		}
+		if err := walker(e.key, trie.readValue(*e.node.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClearDirty clears the dirty mark of every value, such as after syncing the
+// key/values visited by WalkDirty.
+func (trie *pathTrie[T]) ClearDirty() {
+	if trie.config.dirty != nil {
+		clear(trie.config.dirty)
+	}
+}
@@ -0,0 +1,81 @@
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPathTrieWithDirtyTracking(t *testing.T) {
+	trie := NewPathTrie(WithDirtyTracking[int]())
+	dirtyKeys := func() map[string]int {
+		dirty := map[string]int{}
+		if err := trie.WalkDirty(func(key string, value int) error {
+			dirty[key] = value
+			return nil
+		}); err != nil {
+			t.Fatalf("expected error nil, got %v", err)
+		}
+		return dirty
+	}
+
+	trie.Put("/cat", 1)
+	trie.Put("/cat/gideon", 2)
+	trie.Put("/dog", 3)
+	if dirty := dirtyKeys(); !reflect.DeepEqual(dirty, map[string]int{"/cat": 1, "/cat/gideon": 2, "/dog": 3}) {
+		t.Errorf("expected all keys dirty, got %v", dirty)
+	}
+
+	// ClearDirty resets the set
+	trie.ClearDirty()
+	if dirty := dirtyKeys(); len(dirty) != 0 {
+		t.Errorf("expected no dirty keys after ClearDirty, got %v", dirty)
+	}
+
+	// only modified entries are walked, deleted keys are forgotten
+	trie.Put("/cat", 4)
+	trie.PutPath([]string{"/dog", "/rex"}, 5)
+	trie.Put("/fish", 6)
+	trie.Delete("/fish")
+	if dirty := dirtyKeys(); !reflect.DeepEqual(dirty, map[string]int{"/cat": 4, "/dog/rex": 5}) {
+		t.Errorf("expected modified keys dirty, got %v", dirty)
+	}
+	if _, ok := trie.Get("/cat/gideon"); !ok {
+		t.Errorf("expected ClearDirty to keep values")
+	}
+
+	// walker errors abort the walk
+	var visited []string
+	err := trie.WalkDirty(func(key string, value int) error {
+		visited = append(visited, key)
+		return errors.New("stop")
+	})
+	if err == nil || len(visited) != 1 {
+		t.Errorf("expected walk to stop with an error after 1 key, got %v after %v", err, visited)
+	}
+
+	// walkers may delete dirty keys
+	var deleted []string
+	trie.WalkDirty(func(key string, value int) error {
+		deleted = append(deleted, key)
+		trie.Delete("/cat")
+		trie.Delete("/dog/rex")
+		return nil
+	})
+	if len(deleted) != 1 {
+		t.Errorf("expected deleted dirty keys to be skipped, got %v", deleted)
+	}
+
+	// untracked tries walk nothing
+	untracked := NewPathTrie[int]()
+	untracked.Put("/cat", 1)
+	untracked.ClearDirty()
+	var keys []string
+	untracked.WalkDirty(func(key string, value int) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if len(keys) != 0 {
+		t.Errorf("expected no dirty keys without tracking, got %v", keys)
+	}
+}
@@ -7,6 +7,12 @@ of heap memory (i.e. garbage) per Get.
 
 The Tries do not synchronize access (not thread-safe). A typical use case is
 to perform Puts and Deletes upfront to populate the Trie, then perform Gets
-very quickly.
+very quickly. Walking a Trie while another goroutine performs Puts or Deletes
+may crash the program with a "concurrent map iteration and map write" error.
+A walker may itself Put or Delete keys on the goroutine performing the Walk.
+
+Get, Walk, WalkPath, IsEmpty, and TopLevelCount treat a nil trie pointer as an
+empty trie rather than panicking. Calling methods on a nil Trie interface
+value still panics.
 */
 package trie
@@ -0,0 +1,84 @@
+package trie
+
+import (
+	"container/list"
+	"slices"
+	"strings"
+)
+
+// WithMaxEntries bounds the number of values in the path trie to n, such as
+// for a memory capped cache. When a Put adds a value beyond the bound, the
+// earliest inserted values are deleted and passed to onEvict, if set, such
+// as to write them back to a store, with their keys as Walk reports them. Replacing a value keeps its position,
+// while deleting and re-inserting a key moves it to the end. Values added
+// by Put, PutChecked, PutPath, PutRef, loaders, and SortedBuilder count
+// towards the bound. A bound of zero or less is ignored.
+func WithMaxEntries[T any](n int, onEvict func(key string, value T)) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) {
+		if n < 1 {
+			return
+		}
+		trie.config.maxEntries = n
+		trie.config.onEvict = onEvict
+		trie.config.evictOrder = list.New()
+		trie.config.evictElems = map[*pathTrie[T]]*list.Element{}
+	}
+}
+
+// evictEntry is a value node tracked for eviction and the segments leading
+// to it.
+type evictEntry[T any] struct {
+	parts []string
+	node  *pathTrie[T]
+}
+
+// trackEntry records a new value node reached by the given segments for
+// eviction and evicts the earliest inserted values while the trie is over
+// its bound.
+func (trie *pathTrie[T]) trackEntry(parts []string, node *pathTrie[T]) {
+	if trie.config.evictOrder == nil {
+		return
+	}
+	elem := trie.config.evictOrder.PushBack(evictEntry[T]{parts: slices.Clone(parts), node: node})
+	trie.config.evictElems[node] = elem
+	for trie.config.evictOrder.Len() > trie.config.maxEntries {
+		// always take the oldest entry off the list so eviction ends
+		front := trie.config.evictOrder.Front()
+		trie.config.evictOrder.Remove(front)
+		oldest := front.Value.(evictEntry[T])
+		delete(trie.config.evictElems, oldest.node)
+		value := *oldest.node.value
+		trie.evictNode(oldest.parts, oldest.node)
+		if trie.config.onEvict != nil {
+			trie.config.onEvict(strings.Join(oldest.parts, ""), value)
+		}
+	}
+}
+
+// evictNode deletes the value of the node reached by the given segments and
+// removes the ancestors it leaves empty, like Delete, without segmenting a
+// key again.
+func (trie *pathTrie[T]) evictNode(parts []string, node *pathTrie[T]) {
+	path := make([]nodeStr[T], 0, len(parts)) // record ancestors to check later
+	current := trie
+	for _, part := range parts {
+		path = append(path, nodeStr[T]{part: part, node: current})
+		if current = current.child(part); current == nil {
+			break
+		}
+	}
+	if current != node {
+		// the node is no longer at its segments, so only clear its value
+		node.clearValue()
+		return
+	}
+	node.deleteValue(path)
+}
+
+// untrackEntry removes the node from eviction tracking, if tracked.
+func (trie *pathTrie[T]) untrackEntry() {
+	if elem, ok := trie.config.evictElems[trie]; ok {
+		trie.config.evictOrder.Remove(elem)
+		delete(trie.config.evictElems, trie)
+	}
+}
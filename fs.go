@@ -0,0 +1,25 @@
+package trie
+
+import "io/fs"
+
+// NewTrieFromFS walks the file tree rooted at root in fsys, as fs.WalkDir
+// does, and returns a path trie holding each walked path for which valFn
+// returns true, with the value valFn returns. Paths are slash separated as
+// in fs.FS, such as "docs/guide/intro.md". Returns the first error walking
+// the file tree.
+func NewTrieFromFS[T any](fsys fs.FS, root string, valFn func(path string, d fs.DirEntry) (T, bool)) (PathTrie[T], error) {
+	trie := NewPathTrie[T]()
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if value, ok := valFn(path, d); ok {
+			trie.Put(path, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trie, nil
+}
@@ -0,0 +1,67 @@
+package trie
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewTrieFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/index.md":       {Data: []byte("# index")},
+		"docs/guide/intro.md": {Data: []byte("# intro")},
+		"src/main.go":         {Data: []byte("package main")},
+	}
+	// index file sizes
+	trie, err := NewTrieFromFS(fsys, ".", func(path string, d fs.DirEntry) (int64, bool) {
+		if d.IsDir() {
+			return 0, false
+		}
+		info, err := d.Info()
+		if err != nil {
+			return 0, false
+		}
+		return info.Size(), true
+	})
+	if err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	walked := make(map[string]int64)
+	trie.Walk(func(key string, value int64) error {
+		walked[key] = value
+		return nil
+	})
+	expected := map[string]int64{"docs/index.md": 7, "docs/guide/intro.md": 7, "src/main.go": 12}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected key/values %v, got %v", expected, walked)
+	}
+	if children := trie.ChildrenKeys("docs"); !reflect.DeepEqual(children, []string{"docs/guide", "docs/index.md"}) {
+		t.Errorf("expected children of docs [docs/guide docs/index.md], got %v", children)
+	}
+
+	// walk a subtree, including directories
+	dirs, err := NewTrieFromFS(fsys, "docs", func(path string, d fs.DirEntry) (bool, bool) {
+		return d.IsDir(), true
+	})
+	if err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	for key, isDir := range map[string]bool{"docs": true, "docs/guide": true, "docs/guide/intro.md": false} {
+		if value, ok := dirs.Get(key); !ok || value != isDir {
+			t.Errorf("expected key %s to have value %t, got %t", key, isDir, value)
+		}
+	}
+	if _, ok := dirs.Get("src/main.go"); ok {
+		t.Error("expected key src/main.go outside the root to be missing")
+	}
+
+	// a missing root is an error
+	_, err = NewTrieFromFS(fsys, "missing", func(path string, d fs.DirEntry) (bool, bool) {
+		return true, true
+	})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package trie
+
+import (
+	"errors"
+	"sort"
+	"unicode/utf8"
+)
+
+// Fold accumulates a result by calling f with the accumulator and each
+// key/value stored in the trie, starting from init.
+// The order of key/values is unspecified, as with Walk.
+func Fold[T, A any](t Trie[T], init A, f func(acc A, key string, value T) A) A {
+	acc := init
+	t.Walk(func(key string, value T) error {
+		acc = f(acc, key, value)
+		return nil
+	})
+	return acc
+}
+
+// Index builds a path trie from the items, putting the value valFn returns
+// for each item at the key keyFn returns. Later items replace earlier items
+// with the same key.
+func Index[X, T any](items []X, keyFn func(X) string, valFn func(X) T, opts ...PathTrieOption[T]) PathTrie[T] {
+	trie := NewPathTrie(opts...)
+	for _, item := range items {
+		trie.Put(keyFn(item), valFn(item))
+	}
+	return trie
+}
+
+// errNotEqual aborts a walk once tries are known to differ.
+var errNotEqual = errors.New("not equal")
+
+// EqualComparable returns true if the tries hold the same keys with values
+// which are equal according to ==. The tries may be different
+// implementations.
+func EqualComparable[T comparable](a, b Trie[T]) bool {
+	values := make(map[string]T)
+	a.Walk(func(key string, value T) error {
+		values[key] = value
+		return nil
+	})
+	var count int
+	err := b.Walk(func(key string, value T) error {
+		if v, ok := values[key]; !ok || v != value {
+			return errNotEqual
+		}
+		count++
+		return nil
+	})
+	return err == nil && count == len(values)
+}
+
+// ToPathTrie returns a new path trie holding each key/value stored in the
+// trie. Options configure the new path trie.
+func ToPathTrie[T any](t Trie[T], opts ...PathTrieOption[T]) PathTrie[T] {
+	trie := NewPathTrie(opts...)
+	t.Walk(func(key string, value T) error {
+		trie.Put(key, value)
+		return nil
+	})
+	return trie
+}
+
+// ToRuneTrie returns a new rune trie holding each key/value stored in the
+// trie. Options configure the new rune trie.
+func ToRuneTrie[T any](t Trie[T], opts ...RuneTrieOption[T]) RuneTrie[T] {
+	trie := NewRuneTrie(opts...)
+	t.Walk(func(key string, value T) error {
+		trie.Put(key, value)
+		return nil
+	})
+	return trie
+}
+
+// GroupByPrefixDepth groups the key/values stored in the trie by the prefix
+// of their key with the given depth, in segments for path tries or runes
+// for rune tries, and aggregates each group. Each group's accumulator starts
+// from init and is updated by add with each key/value in the group. Keys
+// shorter than depth form their own group.
+// The order of key/values within a group is unspecified, as with Walk.
+func GroupByPrefixDepth[T, A any](t Trie[T], depth int, init func() A, add func(acc A, key string, value T) A) map[string]A {
+	groups := make(map[string]A)
+	t.Walk(func(key string, value T) error {
+		prefix := keyPrefix(t, key, depth)
+		acc, ok := groups[prefix]
+		if !ok {
+			acc = init()
+		}
+		groups[prefix] = add(acc, key, value)
+		return nil
+	})
+	return groups
+}
+
+// keyPrefix returns the prefix of the key with the given depth, in segments
+// for path tries or runes otherwise.
+func keyPrefix[T any](t Trie[T], key string, depth int) string {
+	end := 0
+	if pt, ok := t.(*pathTrie[T]); ok {
+		for part, i := pt.config.segmenter(key, 0); part != "" && depth > 0; part, i = pt.config.segmenter(key, i) {
+			if end = i; i == -1 {
+				return key
+			}
+			depth--
+		}
+		return key[:end]
+	}
+	for _, r := range key {
+		if depth == 0 {
+			break
+		}
+		end += utf8.RuneLen(r)
+		depth--
+	}
+	return key[:end]
+}
+
+// rekey puts each key/value of src into dst at the key the transform returns
+// for its key, in sorted order of the original keys so the value of the
+// largest key wins any collision. Returns dst.
+func rekey[T any](src Trie[T], dst CommonTrie[T], transform func(oldKey string) string) CommonTrie[T] {
+	var entries []KeyValue[T]
+	src.Walk(func(key string, value T) error {
+		entries = append(entries, KeyValue[T]{Key: key, Value: value})
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	for _, entry := range entries {
+		dst.Put(transform(entry.Key), entry.Value)
+	}
+	return dst
+}
+
+// dryRunDeletePrefix returns the number of keys under the prefix, including
+// the prefix itself, and the sorted keys.
+func dryRunDeletePrefix[T any](trie CommonTrie[T], prefix string) (int, []string) {
+	var keys []string
+	for key := range trie.PrefixSeq(prefix) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return len(keys), keys
+}
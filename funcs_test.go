@@ -0,0 +1,285 @@
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestChainWalkers(t *testing.T) {
+	var calls []string
+	walker := func(name string, err error) WalkFunc[int] {
+		return func(key string, value int) error {
+			calls = append(calls, name+key)
+			return err
+		}
+	}
+	chained := ChainWalkers(walker("a", nil), walker("b", nil))
+	if err := chained("/cat", 1); err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if expected := []string{"a/cat", "b/cat"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+
+	// the first error stops the chain
+	calls = nil
+	walkerError := errors.New("walker error")
+	chained = ChainWalkers(walker("a", nil), walker("b", walkerError), walker("c", nil))
+	if err := chained("/dog", 2); err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+	if expected := []string{"a/dog", "b/dog"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+
+	// no walkers
+	if err := ChainWalkers[int]()("/dog", 2); err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+}
+
+func TestFold(t *testing.T) {
+	for _, trie := range []Trie[int]{NewRuneTrie[int](), NewPathTrie[int]()} {
+		table := map[string]int{
+			"":           1,
+			"/cat":       2,
+			"/cat/kitty": 3,
+			"/dog":       4,
+		}
+		for key, value := range table {
+			trie.Put(key, value)
+		}
+
+		sum := Fold(trie, 0, func(acc int, key string, value int) int {
+			return acc + value
+		})
+		if sum != 10 {
+			t.Errorf("expected sum 10, got %d", sum)
+		}
+
+		keys := Fold(trie, []string{}, func(acc []string, key string, value int) []string {
+			return append(acc, key)
+		})
+		// order is unspecified
+		sort.Strings(keys)
+		if joined := strings.Join(keys, ","); joined != ",/cat,/cat/kitty,/dog" {
+			t.Errorf("expected concatenated keys ,/cat,/cat/kitty,/dog, got %s", joined)
+		}
+
+		empty := Fold(NewPathTrie[int](), "init", func(acc string, key string, value int) string {
+			return acc + key
+		})
+		if empty != "init" {
+			t.Errorf("expected fold of empty trie to return init, got %s", empty)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	type pet struct {
+		name    string
+		species string
+		age     int
+	}
+	pets := []pet{
+		{"gideon", "cat", 3},
+		{"giddy", "cat", 5},
+		{"rex", "dog", 7},
+	}
+	trie := Index(pets,
+		func(p pet) string { return "/" + p.species + "/" + p.name },
+		func(p pet) int { return p.age },
+	)
+	cases := map[string]int{
+		"/cat/gideon": 3,
+		"/cat/giddy":  5,
+		"/dog/rex":    7,
+	}
+	for key, age := range cases {
+		if value, ok := trie.Get(key); !ok || value != age {
+			t.Errorf("expected key %s to have value %d, got %d", key, age, value)
+		}
+	}
+	if value, ok := trie.Get("/cat"); ok {
+		t.Errorf("expected key /cat to be missing, found value %v", value)
+	}
+
+	// options configure the path trie
+	trie = Index(pets,
+		func(p pet) string { return p.species + "." + p.name },
+		func(p pet) int { return p.age },
+		WithSegmenter[int](testPathSegmenterDot),
+	)
+	var walked []string
+	trie.WalkPath("dog.rex", func(key string, value int) error {
+		walked = append(walked, key)
+		return nil
+	})
+	if len(walked) != 1 || walked[0] != "dog.rex" {
+		t.Errorf("expected WalkPath to walk [dog.rex], got %v", walked)
+	}
+}
+
+func TestGroupByPrefixDepth(t *testing.T) {
+	trie := NewPathTrie[int]()
+	table := map[string]int{
+		"":                1,
+		"/cat":            2,
+		"/cat/gideon":     3,
+		"/cat/gideon/paw": 4,
+		"/cat/mochi":      5,
+		"/dog/rex":        6,
+	}
+	for key, value := range table {
+		trie.Put(key, value)
+	}
+	zero := func() int { return 0 }
+	sum := func(acc int, key string, value int) int { return acc + value }
+
+	cases := map[int]map[string]int{
+		0: {"": 21},
+		1: {"": 1, "/cat": 14, "/dog": 6},
+		2: {"": 1, "/cat": 2, "/cat/gideon": 7, "/cat/mochi": 5, "/dog/rex": 6},
+	}
+	for depth, expected := range cases {
+		if groups := GroupByPrefixDepth(trie, depth, zero, sum); !reflect.DeepEqual(groups, expected) {
+			t.Errorf("expected depth %d groups %v, got %v", depth, expected, groups)
+		}
+	}
+
+	// rune tries group by leading runes
+	runeTrie := NewRuneTrie[int]()
+	for key, value := range map[string]int{"cat": 1, "cow": 2, "dog": 3, "這是": 4, "這裡": 5} {
+		runeTrie.Put(key, value)
+	}
+	keys := GroupByPrefixDepth(runeTrie, 1, func() []string { return nil }, func(acc []string, key string, value int) []string {
+		return append(acc, key)
+	})
+	for _, group := range keys {
+		sort.Strings(group)
+	}
+	expected := map[string][]string{"c": {"cat", "cow"}, "d": {"dog"}, "這": {"這是", "這裡"}}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected depth 1 groups %v, got %v", expected, keys)
+	}
+}
+
+func TestToPathTrieToRuneTrie(t *testing.T) {
+	table := map[string]int{
+		"":           1,
+		"/cat":       2,
+		"/cat/kitty": 3,
+		"/dog":       4,
+		"fish":       5,
+		"這是":         6,
+	}
+	trie := NewPathTrie[int]()
+	for key, value := range table {
+		trie.Put(key, value)
+	}
+	rt := ToRuneTrie[int](trie)
+	pt := ToPathTrie[int](rt)
+	for _, converted := range []Trie[int]{rt, pt} {
+		walked := make(map[string]int)
+		converted.Walk(func(key string, value int) error {
+			walked[key] = value
+			return nil
+		})
+		if !reflect.DeepEqual(walked, table) {
+			t.Errorf("expected converted trie to hold %v, got %v", table, walked)
+		}
+	}
+	if _, ok := rt.(*runeTrie[int]); !ok {
+		t.Errorf("expected a rune trie, got %T", rt)
+	}
+
+	// options configure the new trie
+	lower := ToPathTrie[int](rt, WithKeyNormalizer[int](strings.ToLower))
+	if value, ok := lower.Get("/CAT"); !ok || value != 2 {
+		t.Errorf("expected key /CAT to have value 2, got %v", value)
+	}
+}
+
+// mapTrie implements Trie with a map, like a Trie implemented outside this
+// package, ignoring WalkPath.
+type mapTrie map[string]int
+
+func (m mapTrie) Get(key string) (int, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+func (m mapTrie) Put(key string, value int) bool {
+	_, ok := m[key]
+	m[key] = value
+	return !ok
+}
+
+func (m mapTrie) Delete(key string) bool {
+	_, ok := m[key]
+	delete(m, key)
+	return ok
+}
+
+func (m mapTrie) Walk(walker WalkFunc[int]) error {
+	for key, value := range m {
+		if err := walker(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m mapTrie) WalkPath(key string, walker WalkFunc[int]) error {
+	return nil
+}
+
+func TestFuncsWithTrieImplementation(t *testing.T) {
+	var trie Trie[int] = mapTrie{"/cat": 1, "/cat/kitty": 2, "/dog": 3}
+	if sum := Fold(trie, 0, func(acc int, key string, value int) int { return acc + value }); sum != 6 {
+		t.Errorf("expected sum 6, got %d", sum)
+	}
+	pt := ToPathTrie(trie)
+	if !EqualComparable(trie, pt) {
+		t.Error("expected converted path trie to equal the map trie")
+	}
+	if keys := pt.ChildrenKeys("/cat"); !reflect.DeepEqual(keys, []string{"/cat/kitty"}) {
+		t.Errorf("expected children [/cat/kitty], got %v", keys)
+	}
+}
+
+func TestEqualComparable(t *testing.T) {
+	table := map[string]int{"": 1, "/cat": 2, "/cat/kitty": 3, "fish": 4}
+	a, b := NewPathTrie[int](), NewRuneTrie[int]()
+	for key, value := range table {
+		a.Put(key, value)
+		b.Put(key, value)
+	}
+	if !EqualComparable[int](a, b) || !EqualComparable[int](b, a) {
+		t.Error("expected tries with the same key/values to be equal")
+	}
+	if !EqualComparable[int](NewPathTrie[int](), NewRuneTrie[int]()) {
+		t.Error("expected empty tries to be equal")
+	}
+
+	// differing value
+	b.Put("/cat", 9)
+	if EqualComparable[int](a, b) {
+		t.Error("expected tries with different values to not be equal")
+	}
+	b.Put("/cat", 2)
+
+	// extra key on either side
+	b.Put("/dog", 5)
+	if EqualComparable[int](a, b) || EqualComparable[int](b, a) {
+		t.Error("expected tries with different keys to not be equal")
+	}
+	b.Delete("/dog")
+	a.Touch("/dog") // internal nodes are not values
+	if !EqualComparable[int](a, b) {
+		t.Error("expected tries to be equal again")
+	}
+}
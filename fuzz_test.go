@@ -0,0 +1,100 @@
+package trie
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// fuzz operations applied to a trie and a map oracle
+const (
+	fuzzPut byte = iota
+	fuzzGet
+	fuzzDelete
+	numFuzzOps
+)
+
+// encodeFuzzOp encodes an operation on a key as fuzz input.
+func encodeFuzzOp(op byte, key string) []byte {
+	return append([]byte{op, byte(len(key))}, key...)
+}
+
+func FuzzTrie(f *testing.F) {
+	seeds := [][]byte{
+		// empty keys and trailing slashes
+		append(encodeFuzzOp(fuzzPut, ""), encodeFuzzOp(fuzzPut, "/")...),
+		append(encodeFuzzOp(fuzzPut, "/a/"), encodeFuzzOp(fuzzDelete, "/a")...),
+		append(encodeFuzzOp(fuzzPut, "/"), encodeFuzzOp(fuzzDelete, "")...),
+	}
+	// shared prefixes with deletes of ancestors and descendants
+	var shared []byte
+	for _, op := range []struct {
+		op  byte
+		key string
+	}{
+		{fuzzPut, "/a"}, {fuzzPut, "/a/b"}, {fuzzPut, "/a/bc"}, {fuzzPut, "ab"},
+		{fuzzDelete, "/a"}, {fuzzGet, "/a/b"}, {fuzzDelete, "/a/b"}, {fuzzGet, "/a/bc"},
+		{fuzzPut, "a"}, {fuzzDelete, "ab"}, {fuzzDelete, "/a/bc"}, {fuzzPut, "/a/b/"},
+	} {
+		shared = append(shared, encodeFuzzOp(op.op, op.key)...)
+	}
+	seeds = append(seeds, shared)
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzTrie(t, NewRuneTrie[int](), data)
+		fuzzTrie(t, NewPathTrie[int](), data)
+	})
+}
+
+// fuzzTrie decodes and applies operations to the trie and a map oracle,
+// checking that they agree.
+func fuzzTrie(t *testing.T, trie CommonTrie[int], data []byte) {
+	oracle := make(map[string]int)
+	for i := 0; len(data) >= 2; i++ {
+		op, keyLen := data[0]%numFuzzOps, minInt(int(data[1]), len(data)-2)
+		key := string(data[2 : 2+keyLen])
+		data = data[2+keyLen:]
+		// rune tries cannot distinguish invalid UTF-8 sequences
+		if !utf8.ValidString(key) {
+			continue
+		}
+
+		switch op {
+		case fuzzPut:
+			_, exists := oracle[key]
+			if isNew := trie.Put(key, i); isNew == exists {
+				t.Fatalf("Put(%q) returned %t, expected %t", key, isNew, !exists)
+			}
+			oracle[key] = i
+		case fuzzDelete:
+			trie.Delete(key)
+			delete(oracle, key)
+		}
+		expected, expectedOK := oracle[key]
+		if value, ok := trie.Get(key); value != expected || ok != expectedOK {
+			t.Fatalf("Get(%q) = (%d, %t), expected (%d, %t)", key, value, ok, expected, expectedOK)
+		}
+	}
+
+	walked := make(map[string]int)
+	trie.Walk(func(key string, value int) error {
+		if _, ok := walked[key]; ok {
+			t.Errorf("Walk visited key %q twice", key)
+		}
+		walked[key] = value
+		return nil
+	})
+	if len(walked) != len(oracle) {
+		t.Fatalf("Walk visited %d keys, expected %d", len(walked), len(oracle))
+	}
+	for key, expected := range oracle {
+		if value, ok := walked[key]; !ok || value != expected {
+			t.Errorf("Walk visited key %q with (%d, %t), expected %d", key, value, ok, expected)
+		}
+	}
+	if empty := trie.IsEmpty(); empty != (len(oracle) == 0) {
+		t.Errorf("IsEmpty() = %t, expected %t", empty, len(oracle) == 0)
+	}
+}
@@ -0,0 +1,101 @@
+package trie
+
+import "strings"
+
+// WalkGlob iterates over each key/value stored in the trie whose key matches
+// the glob pattern and calls the given walker function with the key and
+// value. The pattern is normalized and segmented like keys. A pattern segment ending in "*"
+// matches any single segment which begins with the rest of the pattern
+// segment, so "/*" matches any segment and "/cpu*" matches "/cpu0". A
+// pattern segment ending in "**" matches any number of such segments,
+// including none. For example, "metrics/*/cpu" matches "metrics/host1/cpu"
+// and "metrics/**/cpu" also matches "metrics/dc1/host1/cpu". Only subtrees
+// which can match the pattern are visited. If the walker function returns
+// an error, the walk is aborted.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) WalkGlob(pattern string, walker WalkFunc[T]) error {
+	g := &glob[T]{
+		walker:  walker,
+		visited: make(map[globState[T]]struct{}),
+	}
+	pattern = trie.normalizeKey(pattern)
+	for part, i := trie.config.segmenter(pattern, 0); part != ""; part, i = trie.config.segmenter(pattern, i) {
+		g.segments = append(g.segments, part)
+	}
+	return g.walk(trie, "", 0)
+}
+
+// DeleteGlob removes the values of all keys matching the glob pattern, as
+// matched by WalkGlob, removing nodes left empty as DeleteAll does. Returns
+// the number of keys removed.
+func (trie *pathTrie[T]) DeleteGlob(pattern string) int {
+	var keys []string
+	trie.WalkGlob(pattern, func(key string, value T) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return trie.DeleteAll(keys)
+}
+
+// glob holds the state of a WalkGlob.
+type glob[T any] struct {
+	segments []string
+	walker   WalkFunc[T]
+	// states already walked, since "**" segments may reach a node with the
+	// same remaining pattern in several ways
+	visited map[globState[T]]struct{}
+}
+
+// globState is a node reached with the pattern segments from i remaining.
+type globState[T any] struct {
+	node *pathTrie[T]
+	i    int
+}
+
+func (g *glob[T]) walk(node *pathTrie[T], key string, i int) error {
+	state := globState[T]{node: node, i: i}
+	if _, ok := g.visited[state]; ok {
+		return nil
+	}
+	g.visited[state] = struct{}{}
+
+	if i == len(g.segments) {
+		if node.value != nil {
+			return g.walker(key, node.readValue(*node.value))
+		}
+		return nil
+	}
+	pattern := g.segments[i]
+	prefix := strings.TrimRight(pattern, "*")
+	switch len(pattern) - len(prefix) {
+	case 0:
+		// literal segment
+		if child := node.child(pattern); child != nil {
+			return g.walk(child, key+pattern, i+1)
+		}
+		return nil
+	case 1:
+		// single segment wildcard
+		for _, child := range node.snapshotChildren() {
+			if strings.HasPrefix(child.part, prefix) {
+				if err := g.walk(child.node, key+child.part, i+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	default:
+		// multi segment wildcard, matching no segments or one more segment
+		if err := g.walk(node, key, i+1); err != nil {
+			return err
+		}
+		for _, child := range node.snapshotChildren() {
+			if strings.HasPrefix(child.part, prefix) {
+				if err := g.walk(child.node, key+child.part, i); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
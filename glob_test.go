@@ -0,0 +1,125 @@
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPathTrieWalkGlob(t *testing.T) {
+	trie := NewPathTrie[int]()
+	keys := []string{
+		"metrics",
+		"metrics/host1/cpu",
+		"metrics/host1/mem",
+		"metrics/host2/cpu",
+		"metrics/host2/cpu0",
+		"metrics/dc1/host3/cpu",
+		"metrics/dc1/host3/cpu/user",
+		"logs/host1/cpu",
+	}
+	for i, key := range keys {
+		trie.Put(key, i)
+	}
+
+	cases := map[string][]string{
+		"metrics/host1/cpu": {"metrics/host1/cpu"},
+		"metrics/*/cpu":     {"metrics/host1/cpu", "metrics/host2/cpu"},
+		"metrics/*/cpu*":    {"metrics/host1/cpu", "metrics/host2/cpu", "metrics/host2/cpu0"},
+		"metrics/host*/mem": {"metrics/host1/mem"},
+		"*/host1/cpu":       {"logs/host1/cpu", "metrics/host1/cpu"},
+		"metrics/*":         nil, // no values at depth 2
+		"metrics/**/cpu":    {"metrics/dc1/host3/cpu", "metrics/host1/cpu", "metrics/host2/cpu"},
+		"metrics/**":        keys[:7],
+		"**/cpu":            {"logs/host1/cpu", "metrics/dc1/host3/cpu", "metrics/host1/cpu", "metrics/host2/cpu"},
+		"metrics/**/**/cpu": {"metrics/dc1/host3/cpu", "metrics/host1/cpu", "metrics/host2/cpu"},
+		"**":                keys,
+		"metrics/*/*/cpu":   {"metrics/dc1/host3/cpu"},
+		"missing/**":        nil,
+	}
+	for pattern, expected := range cases {
+		var walked []string
+		err := trie.WalkGlob(pattern, func(key string, value int) error {
+			if keys[value] != key {
+				t.Errorf("expected key %s to have value %d", key, value)
+			}
+			walked = append(walked, key)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("expected error nil, got %v", err)
+		}
+		sort.Strings(walked)
+		expected = append([]string(nil), expected...)
+		sort.Strings(expected)
+		if strings.Join(walked, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected pattern %s to match %v, got %v", pattern, expected, walked)
+		}
+	}
+
+	walkerError := errors.New("walker error")
+	if err := trie.WalkGlob("**", func(key string, value int) error {
+		return walkerError
+	}); err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+}
+
+func TestPathTrieDeleteGlob(t *testing.T) {
+	trie := NewPathTrie[int]()
+	keys := []string{
+		"metrics",
+		"metrics/host1/cpu",
+		"metrics/host1/mem",
+		"metrics/host2/cpu",
+		"metrics/dc1/host3/cpu",
+		"logs/host1/cpu",
+	}
+	for i, key := range keys {
+		trie.Put(key, i)
+	}
+
+	// single segment wildcard
+	if count := trie.DeleteGlob("metrics/*/cpu"); count != 2 {
+		t.Errorf("expected 2 keys deleted, got %d", count)
+	}
+	if nodes, expected := nodeKeys(trie, ""), []string{"logs", "logs/host1", "logs/host1/cpu", "metrics", "metrics/dc1", "metrics/dc1/host3", "metrics/dc1/host3/cpu", "metrics/host1", "metrics/host1/mem"}; !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("expected nodes %v, got %v", expected, nodes)
+	}
+
+	// multi segment wildcard
+	if count := trie.DeleteGlob("**/cpu"); count != 2 {
+		t.Errorf("expected 2 keys deleted, got %d", count)
+	}
+	if nodes, expected := nodeKeys(trie, ""), []string{"metrics", "metrics/host1", "metrics/host1/mem"}; !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("expected nodes %v, got %v", expected, nodes)
+	}
+
+	if count := trie.DeleteGlob("missing/**"); count != 0 {
+		t.Errorf("expected 0 keys deleted, got %d", count)
+	}
+}
+
+func TestPathTrieGlobWithKeyNormalizer(t *testing.T) {
+	trie := NewPathTrie(WithKeyNormalizer[int](strings.ToLower))
+	for i, key := range []string{"/A/B", "/a/C", "/D/e"} {
+		trie.Put(key, i)
+	}
+	var keys []string
+	trie.WalkGlob("/A/*", func(key string, value int) error {
+		keys = append(keys, key)
+		return nil
+	})
+	sort.Strings(keys)
+	if expected := []string{"/a/b", "/a/c"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+	if count := trie.DeleteGlob("/A/*"); count != 2 {
+		t.Errorf("expected 2 keys deleted, got %d", count)
+	}
+	if nodes, expected := nodeKeys(trie, ""), []string{"/d", "/d/e"}; !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("expected nodes %v, got %v", expected, nodes)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/dghubble/trie
 
-go 1.19
+go 1.23.0
+
+require golang.org/x/text v0.28.0
@@ -0,0 +1,82 @@
+package trie
+
+import "sort"
+
+// IndexedTrie is a trie which indexes keys by value, for reverse lookups of
+// the keys holding a value without walking the trie. It is built on a path
+// trie and an index from each value to the set of keys holding it.
+type IndexedTrie[T comparable] struct {
+	trie  PathTrie[T]
+	index map[T]map[string]struct{}
+}
+
+// NewIndexedTrie allocates and returns a new IndexedTrie.
+func NewIndexedTrie[T comparable]() *IndexedTrie[T] {
+	return &IndexedTrie[T]{
+		trie:  NewPathTrie[T](),
+		index: make(map[T]map[string]struct{}),
+	}
+}
+
+// Get returns the value stored at the given key.
+func (t *IndexedTrie[T]) Get(key string) (T, bool) {
+	return t.trie.Get(key)
+}
+
+// Put inserts the value into the trie at the given key, replacing any
+// existing value and moving the key in the index. It returns true if the
+// put adds a new value, false if it replaces an existing value.
+func (t *IndexedTrie[T]) Put(key string, value T) bool {
+	if old, ok := t.trie.Get(key); ok {
+		t.unindex(key, old)
+	}
+	keys := t.index[value]
+	if keys == nil {
+		keys = make(map[string]struct{})
+		t.index[value] = keys
+	}
+	keys[key] = struct{}{}
+	return t.trie.Put(key, value)
+}
+
+// Delete removes the value associated with the given key. Returns true if
+// the key had a value.
+func (t *IndexedTrie[T]) Delete(key string) bool {
+	old, ok := t.trie.Get(key)
+	if !ok {
+		return false
+	}
+	t.unindex(key, old)
+	return t.trie.Delete(key)
+}
+
+// unindex removes the key from the keys holding the value.
+func (t *IndexedTrie[T]) unindex(key string, value T) {
+	keys := t.index[value]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(t.index, value)
+	}
+}
+
+// KeysForValue returns the keys holding the given value, in sorted order.
+func (t *IndexedTrie[T]) KeysForValue(value T) []string {
+	keys := t.index[value]
+	if len(keys) == 0 {
+		return nil
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Walk iterates over each key/value stored in the trie and calls the given
+// walker function with the key and value. If the walker function returns an
+// error, the walk is aborted. The walker must not modify the trie.
+// The traversal is depth first with no guaranteed order.
+func (t *IndexedTrie[T]) Walk(walker WalkFunc[T]) error {
+	return t.trie.Walk(walker)
+}
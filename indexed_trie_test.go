@@ -0,0 +1,63 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndexedTrie(t *testing.T) {
+	trie := NewIndexedTrie[string]()
+	if isNew := trie.Put("/cat/gideon", "cat"); !isNew {
+		t.Error("expected key /cat/gideon to be missing")
+	}
+	trie.Put("/cat/mochi", "cat")
+	trie.Put("/dog/rex", "dog")
+	trie.Put("/cat/apollo", "cat")
+	if keys := trie.KeysForValue("cat"); !reflect.DeepEqual(keys, []string{"/cat/apollo", "/cat/gideon", "/cat/mochi"}) {
+		t.Errorf("expected cat keys [/cat/apollo /cat/gideon /cat/mochi], got %v", keys)
+	}
+	if keys := trie.KeysForValue("fish"); keys != nil {
+		t.Errorf("expected no fish keys, got %v", keys)
+	}
+
+	// overwrites move the key to the new value
+	if isNew := trie.Put("/cat/mochi", "dog"); isNew {
+		t.Error("expected key /cat/mochi to be replaced")
+	}
+	if keys := trie.KeysForValue("cat"); !reflect.DeepEqual(keys, []string{"/cat/apollo", "/cat/gideon"}) {
+		t.Errorf("expected cat keys [/cat/apollo /cat/gideon], got %v", keys)
+	}
+	if keys := trie.KeysForValue("dog"); !reflect.DeepEqual(keys, []string{"/cat/mochi", "/dog/rex"}) {
+		t.Errorf("expected dog keys [/cat/mochi /dog/rex], got %v", keys)
+	}
+	trie.Put("/dog/rex", "dog")
+	if keys := trie.KeysForValue("dog"); len(keys) != 2 {
+		t.Errorf("expected 2 dog keys, got %v", keys)
+	}
+
+	// deletes remove the key from the index
+	if !trie.Delete("/dog/rex") {
+		t.Error("expected key /dog/rex to be deleted")
+	}
+	if trie.Delete("/dog/rex") || trie.Delete("/cat") {
+		t.Error("expected missing keys to not be deleted")
+	}
+	trie.Delete("/cat/mochi")
+	if keys := trie.KeysForValue("dog"); keys != nil {
+		t.Errorf("expected no dog keys, got %v", keys)
+	}
+	if _, ok := trie.index["dog"]; ok {
+		t.Error("expected empty index entries to be removed")
+	}
+	if value, ok := trie.Get("/cat/gideon"); !ok || value != "cat" {
+		t.Errorf("expected key /cat/gideon to have value cat, got %s", value)
+	}
+	walked := make(map[string]string)
+	trie.Walk(func(key string, value string) error {
+		walked[key] = value
+		return nil
+	})
+	if expected := map[string]string{"/cat/apollo": "cat", "/cat/gideon": "cat"}; !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected walked %v, got %v", expected, walked)
+	}
+}
@@ -0,0 +1,39 @@
+package trie
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonEntry is a key/value in a JSON stream.
+type jsonEntry[T any] struct {
+	Key   string `json:"key"`
+	Value T      `json:"value"`
+}
+
+// EncodeJSONStream writes each key/value stored in the trie to w as a JSON
+// object {"key": ..., "value": ...} on its own line (JSON lines), as the
+// trie is walked, so memory use does not grow with the size of the trie.
+// Values are encoded with encoding/json. Returns the first encoding or
+// write error.
+// The order of key/values is unspecified, as with Walk.
+func (trie *runeTrie[T]) EncodeJSONStream(w io.Writer) error {
+	return encodeJSONStream[T](trie, w)
+}
+
+// EncodeJSONStream writes each key/value stored in the trie to w as a JSON
+// object {"key": ..., "value": ...} on its own line (JSON lines), as the
+// trie is walked, so memory use does not grow with the size of the trie.
+// Values are encoded with encoding/json. Returns the first encoding or
+// write error.
+// The order of key/values is unspecified, as with Walk.
+func (trie *pathTrie[T]) EncodeJSONStream(w io.Writer) error {
+	return encodeJSONStream[T](trie, w)
+}
+
+func encodeJSONStream[T any](trie Trie[T], w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return trie.Walk(func(key string, value T) error {
+		return enc.Encode(jsonEntry[T]{Key: key, Value: value})
+	})
+}
@@ -0,0 +1,71 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type jsonValue struct {
+	Name string
+	Tags []string
+}
+
+func TestEncodeJSONStream(t *testing.T) {
+	for _, trie := range []CommonTrie[jsonValue]{NewRuneTrie[jsonValue](), NewPathTrie[jsonValue]()} {
+		source := map[string]jsonValue{
+			"":           {Name: "root"},
+			"/cat":       {Name: "cat", Tags: []string{"pet"}},
+			"/cat/這是":    {Name: "unicode"},
+			"/dog/\"x\"": {Name: "quoted"},
+		}
+		for key, value := range source {
+			trie.Put(key, value)
+		}
+		var buf bytes.Buffer
+		if err := trie.EncodeJSONStream(&buf); err != nil {
+			t.Fatalf("expected error nil, got %v", err)
+		}
+		if lines := strings.Count(buf.String(), "\n"); lines != len(source) {
+			t.Errorf("expected %d lines, got %d", len(source), lines)
+		}
+		decoded := make(map[string]jsonValue)
+		dec := json.NewDecoder(&buf)
+		for {
+			var entry struct {
+				Key   string    `json:"key"`
+				Value jsonValue `json:"value"`
+			}
+			if err := dec.Decode(&entry); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("expected error nil, got %v", err)
+			}
+			decoded[entry.Key] = entry.Value
+		}
+		if !reflect.DeepEqual(decoded, source) {
+			t.Errorf("expected decoded key/values %v, got %v", source, decoded)
+		}
+	}
+}
+
+func TestEncodeJSONStreamError(t *testing.T) {
+	trie := NewPathTrie[int]()
+	trie.Put("/a", 1)
+	writeErr := errors.New("write error")
+	if err := trie.EncodeJSONStream(errWriter{writeErr}); err != writeErr {
+		t.Errorf("expected write error, got %v", err)
+	}
+}
+
+type errWriter struct {
+	err error
+}
+
+func (w errWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
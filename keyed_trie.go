@@ -0,0 +1,37 @@
+package trie
+
+// KeyedTrie is a trie with keys of type K, which are encoded to string keys
+// of a path trie by a codec. The codec must be deterministic and encode
+// distinct keys to distinct strings, such as by joining fields with
+// slashes so that related keys share prefixes.
+type KeyedTrie[K any, T any] struct {
+	trie  PathTrie[T]
+	codec func(K) string
+}
+
+// NewKeyedTrie allocates and returns a new KeyedTrie which encodes keys with
+// the codec. Options configure the underlying path trie.
+func NewKeyedTrie[K any, T any](codec func(K) string, opts ...PathTrieOption[T]) *KeyedTrie[K, T] {
+	return &KeyedTrie[K, T]{
+		trie:  NewPathTrie(opts...),
+		codec: codec,
+	}
+}
+
+// Get returns the value stored at the given key.
+func (t *KeyedTrie[K, T]) Get(key K) (T, bool) {
+	return t.trie.Get(t.codec(key))
+}
+
+// Put inserts the value into the trie at the given key, replacing any
+// existing value. It returns true if the put adds a new value, false if it
+// replaces an existing value.
+func (t *KeyedTrie[K, T]) Put(key K, value T) bool {
+	return t.trie.Put(t.codec(key), value)
+}
+
+// Delete removes the value associated with the given key. Returns true if a
+// node was found for the given key.
+func (t *KeyedTrie[K, T]) Delete(key K) bool {
+	return t.trie.Delete(t.codec(key))
+}
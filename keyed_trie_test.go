@@ -0,0 +1,46 @@
+package trie
+
+import (
+	"fmt"
+	"testing"
+)
+
+type petKey struct {
+	species string
+	name    string
+	age     int
+}
+
+func encodePetKey(k petKey) string {
+	return fmt.Sprintf("/%s/%s/%d", k.species, k.name, k.age)
+}
+
+func TestKeyedTrie(t *testing.T) {
+	trie := NewKeyedTrie[petKey, string](encodePetKey)
+	gideon := petKey{species: "cat", name: "gideon", age: 3}
+	if isNew := trie.Put(gideon, "orange"); !isNew {
+		t.Error("expected key gideon to be missing")
+	}
+	trie.Put(petKey{species: "dog", name: "rex", age: 5}, "brown")
+
+	// equal keys encode to the same string key
+	if value, ok := trie.Get(petKey{species: "cat", name: "gideon", age: 3}); !ok || value != "orange" {
+		t.Errorf("expected key gideon to have value orange, got %s", value)
+	}
+	if value, ok := trie.Get(petKey{species: "cat", name: "gideon", age: 4}); ok {
+		t.Errorf("expected key gideon aged 4 to be missing, got %s", value)
+	}
+	if isNew := trie.Put(gideon, "ginger"); isNew {
+		t.Error("expected key gideon to be replaced")
+	}
+	if value, _ := trie.trie.Get("/cat/gideon/3"); value != "ginger" {
+		t.Errorf("expected encoded key /cat/gideon/3 to have value ginger, got %s", value)
+	}
+
+	if !trie.Delete(gideon) {
+		t.Error("expected key gideon to be deleted")
+	}
+	if _, ok := trie.Get(gideon); ok {
+		t.Error("expected key gideon to be missing")
+	}
+}
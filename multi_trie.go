@@ -0,0 +1,72 @@
+package trie
+
+// MultiTrie is a trie which stores multiple values per key. It is built on
+// a path trie of value slices.
+type MultiTrie[T any] struct {
+	trie PathTrie[[]T]
+}
+
+// NewMultiTrie allocates and returns a new MultiTrie. Options configure the
+// underlying path trie.
+func NewMultiTrie[T any](opts ...PathTrieOption[[]T]) *MultiTrie[T] {
+	return &MultiTrie[T]{
+		trie: NewPathTrie(opts...),
+	}
+}
+
+// Get returns the values stored at the given key, in the order they were
+// put. The returned slice must not be modified.
+func (m *MultiTrie[T]) Get(key string) ([]T, bool) {
+	return m.trie.Get(key)
+}
+
+// Put appends the value to the values stored at the given key. It returns
+// true if the key had no values before.
+func (m *MultiTrie[T]) Put(key string, value T) bool {
+	values, ok := m.trie.Get(key)
+	m.trie.Put(key, append(values, value))
+	return !ok
+}
+
+// Remove removes the first value stored at the given key which is equal to
+// v according to eq. Returns true if a value was removed. When the last
+// value for a key is removed, the key is deleted from the trie.
+func (m *MultiTrie[T]) Remove(key string, eq func(a, b T) bool, v T) bool {
+	values, ok := m.trie.Get(key)
+	if !ok {
+		return false
+	}
+	for i, value := range values {
+		if !eq(value, v) {
+			continue
+		}
+		if len(values) == 1 {
+			m.trie.Delete(key)
+			return true
+		}
+		// copy so previously returned slices are not modified
+		remaining := make([]T, 0, len(values)-1)
+		remaining = append(remaining, values[:i]...)
+		remaining = append(remaining, values[i+1:]...)
+		m.trie.Put(key, remaining)
+		return true
+	}
+	return false
+}
+
+// Delete removes all values stored at the given key. Returns true if the
+// key had values.
+func (m *MultiTrie[T]) Delete(key string) bool {
+	if _, ok := m.trie.Get(key); !ok {
+		return false
+	}
+	return m.trie.Delete(key)
+}
+
+// Walk iterates over each key stored in the trie and calls the given walker
+// function with the key and its values. If the walker function returns an
+// error, the walk is aborted.
+// The traversal is depth first with no guaranteed order.
+func (m *MultiTrie[T]) Walk(walker WalkFunc[[]T]) error {
+	return m.trie.Walk(walker)
+}
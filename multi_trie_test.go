@@ -0,0 +1,87 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMultiTrie(t *testing.T) {
+	trie := NewMultiTrie[int]()
+	eq := func(a, b int) bool { return a == b }
+
+	// multiple appends
+	if isNew := trie.Put("/cat", 1); !isNew {
+		t.Error("expected key /cat to be missing")
+	}
+	for _, value := range []int{2, 3, 2} {
+		if isNew := trie.Put("/cat", value); isNew {
+			t.Error("expected key /cat to have values already")
+		}
+	}
+	trie.Put("/cat/gideon", 5)
+	if values, ok := trie.Get("/cat"); !ok || !reflect.DeepEqual(values, []int{1, 2, 3, 2}) {
+		t.Errorf("expected key /cat to have values [1 2 3 2], got %v", values)
+	}
+
+	// partial removal removes the first match only
+	if !trie.Remove("/cat", eq, 2) {
+		t.Error("expected value 2 to be removed from /cat")
+	}
+	if trie.Remove("/cat", eq, 9) {
+		t.Error("expected value 9 to not be removed from /cat")
+	}
+	if values, ok := trie.Get("/cat"); !ok || !reflect.DeepEqual(values, []int{1, 3, 2}) {
+		t.Errorf("expected key /cat to have values [1 3 2], got %v", values)
+	}
+
+	// full removal cleans up the key and its nodes
+	if !trie.Remove("/cat/gideon", eq, 5) {
+		t.Error("expected value 5 to be removed from /cat/gideon")
+	}
+	if values, ok := trie.Get("/cat/gideon"); ok {
+		t.Errorf("expected key /cat/gideon to be removed, got %v", values)
+	}
+	root := trie.trie.(*pathTrie[[]int])
+	if child := root.child("/cat"); child == nil || !child.isLeaf() {
+		t.Error("expected node /cat/gideon to be cleaned up")
+	}
+	for _, value := range []int{1, 3, 2} {
+		trie.Remove("/cat", eq, value)
+	}
+	if !root.isLeaf() {
+		t.Error("expected node /cat to be cleaned up")
+	}
+	if trie.Remove("/cat", eq, 1) {
+		t.Error("expected missing key /cat to not remove values")
+	}
+
+	// delete removes all values
+	trie.Put("/dog", 1)
+	trie.Put("/dog", 2)
+	if !trie.Delete("/dog") {
+		t.Error("expected key /dog to be deleted")
+	}
+	if trie.Delete("/dog") {
+		t.Error("expected key /dog to be missing")
+	}
+}
+
+func TestMultiTrieWalk(t *testing.T) {
+	trie := NewMultiTrie[string]()
+	trie.Put("/cat", "gideon")
+	trie.Put("/cat", "giddy")
+	trie.Put("/dog", "rex")
+
+	walked := make(map[string][]string)
+	trie.Walk(func(key string, values []string) error {
+		walked[key] = values
+		return nil
+	})
+	expected := map[string][]string{
+		"/cat": {"gideon", "giddy"},
+		"/dog": {"rex"},
+	}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected walked %v, got %v", expected, walked)
+	}
+}
@@ -1,5 +1,17 @@
 package trie
 
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+)
+
 // pathTrie is a trie of paths with string keys and generic type values.
 
 // pathTrie is a trie of string keys and generic type values. By default
@@ -8,9 +20,60 @@ package trie
 // used to customize how strings are segmented into nodes. A classic
 // trie might segment keys by rune (i.e. unicode points).
 type pathTrie[T any] struct {
+	config   *pathTrieConfig[T] // shared by all nodes of the trie
+	value    *T
+	children childStore[T] // nil if the node has no children
+}
+
+// pathTrieConfig holds the configuration of a path trie.
+type pathTrieConfig[T any] struct {
 	segmenter StringSegmenter // key segmenter, must not cause heap allocs
-	value     *T
-	children  map[string]*pathTrie[T]
+	loader    func(key string) (T, bool)
+	validator func(key string, value T) error
+	normalize func(key string) string
+	// transforms segments output by the segmenter, if set
+	transformSegment func(segment string) string
+	// returned by Get for missing keys, if set
+	defaultValue *T
+	// copies values returned by Gets and Walks, if set
+	cloneValue func(T) T
+	// allocates the children of nodes, see withChildStore
+	newChildren func() childStore[T]
+	// seed for a deterministic shuffle of children in Walk, if set
+	walkSeed *int64
+	// walk with a stack rather than recursion
+	iterativeWalk bool
+	// leave emptied nodes in place on Delete, see Prune
+	noDeleteCleanup bool
+	// prune once more than compactThreshold nodes are emptied, if set
+	autoCompact      bool
+	compactThreshold int
+	emptiedNodes     int
+	// insertion sequence of value nodes, if tracked
+	insertionOrder map[*pathTrie[T]]uint64
+	insertionSeq   uint64
+	// version at which each value was last put, if tracked
+	versions map[*pathTrie[T]]uint64
+	version  uint64
+	// values put since ClearDirty, if tracked
+	dirty map[*pathTrie[T]]struct{}
+	// reversed keys of values and the key of each value node, if indexed
+	suffixes   RuneTrie[string]
+	suffixKeys map[*pathTrie[T]]string
+	// key each value was Put with, once PutReportingCollision is used
+	putKeys map[*pathTrie[T]]string
+	// Get hits of each value, if counted
+	accessCounts map[*pathTrie[T]]uint64
+	// values in insertion order and their elements, if bounded
+	maxEntries int
+	onEvict    func(key string, value T)
+	evictOrder *list.List
+	evictElems map[*pathTrie[T]]*list.Element
+	// nodes marked absent by PutTombstone
+	tombstones map[*pathTrie[T]]struct{}
+	// value codec for binary marshaling
+	encodeValue func(T) []byte
+	decodeValue func([]byte) (T, error)
 }
 
 // PathTrieOption is an optional configuration option for a path trie.
@@ -18,41 +81,208 @@ type PathTrieOption[T any] func(*pathTrie[T])
 
 // WithSegmenter sets a non-default StringSegmenter on the path trie.
 func WithSegmenter[T any](s StringSegmenter) PathTrieOption[T] {
-	return func(trie *pathTrie[T]) { trie.segmenter = s }
+	return func(trie *pathTrie[T]) { trie.config.segmenter = s }
+}
+
+// WithLoader sets a loader function which is called when Get misses. If the
+// loader finds a value for the key, the value is Put into the path trie and
+// returned, making the trie a self-populating cache. Loaded values which
+// fail validation are not stored and Get reports a miss. The loader is called
+// on the goroutine calling Get.
+func WithLoader[T any](loader func(key string) (T, bool)) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.loader = loader }
+}
+
+// WithDefault sets a default value which Get and GetPath return, along with
+// false, for keys missing from the trie, rather than the zero value, such
+// as a fallback for config hierarchies. Values found by a loader are
+// returned as usual.
+func WithDefault[T any](value T) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.defaultValue = &value }
+}
+
+// WithValidator sets a validator function which is run before values are
+// put into the path trie. Values which fail validation are not inserted.
+// Use PutChecked to receive the validation error.
+func WithValidator[T any](validator func(key string, value T) error) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.validator = validator }
+}
+
+// WithKeyNormalizer sets a function which normalizes keys passed to Get, Put,
+// PutChecked, Delete, and WalkPath (e.g. lowercasing hosts or stripping
+// query strings). Keys are stored normalized, so Walks return normalized
+// keys. The normalizer should be idempotent.
+func WithKeyNormalizer[T any](normalize func(key string) string) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.normalize = normalize }
+}
+
+// WithSegmentTransform sets a function which transforms each segment of
+// keys passed to Get, Put, Delete, and other methods taking keys, after the
+// segmenter splits the key (e.g. percent-decoding, trimming, or case-folding
+// segments). Keys are stored with transformed segments, so Walks return
+// transformed keys. The transform must not return an empty segment and
+// should be idempotent. Methods taking pre-split segments, such as PutPath,
+// do not transform them.
+func WithSegmentTransform[T any](transform func(segment string) string) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.transformSegment = transform }
+}
+
+// WithReadCloner sets a function which copies every value the trie returns,
+// from Gets, walks, iterators, and lookups such as Find or Parent, so callers
+// mutating a returned value, such as a map or slice, do not modify the stored
+// value. WalkMutate still passes pointers to the stored values. By default
+// stored values are returned as is.
+func WithReadCloner[T any](clone func(T) T) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.cloneValue = clone }
+}
+
+// ErrNoInsertionOrder is returned when walking a path trie in insertion
+// order which does not record insertion order. See WithInsertionOrder.
+var ErrNoInsertionOrder = errors.New("trie: insertion order not recorded")
+
+// WithInsertionOrder records the order in which keys are first inserted so
+// that WalkInsertionOrder can walk keys in that order. Replacing a value
+// keeps its position, while deleting and re-inserting a key moves it to the
+// end. Tracking costs an extra map entry per stored value.
+func WithInsertionOrder[T any]() PathTrieOption[T] {
+	return func(trie *pathTrie[T]) {
+		trie.config.insertionOrder = map[*pathTrie[T]]uint64{}
+	}
+}
+
+// WithoutDeleteCleanup makes Delete only clear the value of the key's node,
+// leaving emptied nodes in the trie rather than removing them from their
+// parents. Workloads which repeatedly delete and re-insert the same keys
+// avoid reallocating nodes and children maps, at the cost of memory. Call
+// Prune to remove emptied nodes.
+func WithoutDeleteCleanup[T any]() PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.noDeleteCleanup = true }
 }
 
 // NewPathTrie allocates and returns a new path implementation of Trie.
-func NewPathTrie[T any](opts ...PathTrieOption[T]) Trie[T] {
+func NewPathTrie[T any](opts ...PathTrieOption[T]) PathTrie[T] {
 	trie := &pathTrie[T]{
-		segmenter: PathSegmenter,
+		config: &pathTrieConfig[T]{
+			segmenter:   PathSegmenter,
+			newChildren: newMapChildren[T],
+		},
 	}
 	for _, opt := range opts {
 		opt(trie)
 	}
+	if transform := trie.config.transformSegment; transform != nil {
+		trie.config.segmenter = transformSegments(trie.config.segmenter, transform)
+	}
 	return trie
 }
 
+// newEmptyTrie returns a new empty trie which segments and normalizes keys
+// like the trie, without its other options.
+func (trie *pathTrie[T]) newEmptyTrie() *pathTrie[T] {
+	return &pathTrie[T]{
+		config: &pathTrieConfig[T]{
+			segmenter:   trie.config.segmenter,
+			normalize:   trie.config.normalize,
+			newChildren: trie.config.newChildren,
+		},
+	}
+}
+
+// transformSegments returns a StringSegmenter which applies the transform to
+// each segment of the given segmenter.
+func transformSegments(segmenter StringSegmenter, transform func(string) string) StringSegmenter {
+	return func(key string, start int) (string, int) {
+		segment, next := segmenter(key, start)
+		if segment == "" {
+			return "", next
+		}
+		return transform(segment), next
+	}
+}
+
 // newPathTrieFromTrie returns new trie while preserving its config
 func (trie *pathTrie[T]) newPathTrieFromTrie() *pathTrie[T] {
 	return &pathTrie[T]{
-		segmenter: trie.segmenter,
+		config: trie.config,
 	}
 }
 
 // Get returns the value stored at the given key. Returns nil for internal
-// nodes or for nodes with a value of nil.
+// nodes or for nodes with a value of nil. If the trie has a loader, a miss
+// calls the loader and stores any value it finds, unless the key has a
+// tombstone.
 func (trie *pathTrie[T]) Get(key string) (T, bool) {
+	if trie == nil {
+		return zeroValueOfT[T](), false
+	}
+	key = trie.normalizeKey(key)
 	node := trie
-	for part, i := trie.segmenter(key, 0); part != ""; part, i = trie.segmenter(key, i) {
-		node = node.children[part]
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		node = node.child(part)
 		if node == nil {
-			return zeroValueOfT[T](), false
+			return trie.load(key)
 		}
 	}
 	if node.value == nil {
-		return zeroValueOfT[T](), false
+		if node.isTombstone() {
+			return trie.missValue(), false
+		}
+		return trie.load(key)
+	}
+	trie.countAccess(node)
+	return trie.readValue(*node.value), true
+}
+
+// missValue returns the value Get returns for a missing key, which is the
+// trie's default value, if set, or the zero value.
+func (trie *pathTrie[T]) missValue() T {
+	if trie.config.defaultValue == nil {
+		return zeroValueOfT[T]()
+	}
+	return trie.readValue(*trie.config.defaultValue)
+}
+
+// readValue returns the value to hand to callers, cloned if the trie has a
+// read cloner.
+func (trie *pathTrie[T]) readValue(value T) T {
+	if trie.config.cloneValue != nil {
+		return trie.config.cloneValue(value)
+	}
+	return value
+}
+
+// GetWithDepth returns the value stored at the given key, as Get does, along
+// with the depth of the key in segments (e.g. 3 for "/a/b/c"), such as to
+// tell the specificity of a route. Returns a depth of 0 if the key is
+// missing.
+func (trie *pathTrie[T]) GetWithDepth(key string) (T, int, bool) {
+	value, ok := trie.Get(key)
+	if !ok {
+		return value, 0, false
+	}
+	var depth int
+	key = trie.normalizeKey(key)
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		depth++
+	}
+	return value, depth, true
+}
+
+// load calls the loader, if one is set, for a key missing from the trie and
+// puts the value into the trie if it was found and passes validation.
+func (trie *pathTrie[T]) load(key string) (T, bool) {
+	if trie.config.loader == nil {
+		return trie.missValue(), false
+	}
+	value, ok := trie.config.loader(key)
+	if !ok {
+		return trie.missValue(), false
 	}
-	return *node.value, true
+	if err := trie.PutChecked(key, value); err != nil {
+		// rejected values are neither stored nor returned
+		return trie.missValue(), false
+	}
+	return trie.readValue(value), true
 }
 
 // Put inserts the value into the trie at the given key, replacing any
@@ -60,122 +290,1460 @@ func (trie *pathTrie[T]) Get(key string) (T, bool) {
 // if it replaces an existing value.
 // Note that internal nodes have nil values so a stored nil value will not
 // be distinguishable and will not be included in Walks.
+// If the trie has a validator, values which fail validation are not
+// inserted and Put returns false.
 func (trie *pathTrie[T]) Put(key string, value T) bool {
+	key = trie.normalizeKey(key)
+	if trie.validate(key, value) != nil {
+		return false
+	}
+	return trie.put(key, &value)
+}
+
+// PutChecked validates the value and inserts it into the trie at the given
+// key, replacing any existing items. If the trie has a validator and the
+// value fails validation, the validation error is returned and the value is
+// not inserted.
+func (trie *pathTrie[T]) PutChecked(key string, value T) error {
+	key = trie.normalizeKey(key)
+	if err := trie.validate(key, value); err != nil {
+		return err
+	}
+	trie.put(key, &value)
+	return nil
+}
+
+// PutRef inserts the value pointer into the trie at the given key, replacing
+// any existing items, without copying the value. The trie shares the
+// pointed-to value with the caller, so later changes through the pointer
+// are visible to Gets and Walks. A nil value is ignored. It returns true if
+// the put adds a new value, false if it replaces an existing value or if
+// the value fails validation.
+func (trie *pathTrie[T]) PutRef(key string, value *T) bool {
+	if value == nil {
+		return false
+	}
+	key = trie.normalizeKey(key)
+	if trie.validate(key, *value) != nil {
+		return false
+	}
+	return trie.put(key, value)
+}
+
+// normalizeKey normalizes the key if the trie has a key normalizer.
+func (trie *pathTrie[T]) normalizeKey(key string) string {
+	if trie.config.normalize == nil {
+		return key
+	}
+	return trie.config.normalize(key)
+}
+
+// validate runs the validator, if one is set, on the key and value.
+func (trie *pathTrie[T]) validate(key string, value T) error {
+	if trie.config.validator == nil {
+		return nil
+	}
+	return trie.config.validator(key, value)
+}
+
+// put inserts the value into the trie at the given key without validation.
+func (trie *pathTrie[T]) put(key string, value *T) bool {
 	node := trie
-	for part, i := trie.segmenter(key, 0); part != ""; part, i = trie.segmenter(key, i) {
-		child := node.children[part]
-		if child == nil {
-			if node.children == nil {
-				node.children = map[string]*pathTrie[T]{}
-			}
-			child = trie.newPathTrieFromTrie()
-			node.children[part] = child
+	// only record the segments if a tracker needs them
+	var parts []string
+	record := trie.config.evictOrder != nil || trie.config.suffixes != nil
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		node = node.putChild(part)
+		if record {
+			parts = append(parts, part)
 		}
-		node = child
 	}
+	return trie.putNode(key, parts, node, value)
+}
+
+// putNode sets the value of the node at the given key and records it with
+// the trie's trackers. The parts are the segments leading to the node, which
+// the caller may reuse. Every Put of a value goes through putNode. It returns
+// true if the node had no value.
+func (trie *pathTrie[T]) putNode(key string, parts []string, node *pathTrie[T], value *T) bool {
+	isNewVal := node.setValue(value)
+	if isNewVal {
+		trie.trackEntry(parts, node)
+	}
+	if trie.config.putKeys != nil {
+		trie.config.putKeys[node] = key
+	}
+	trie.indexSuffix(parts, node)
+	return isNewVal
+}
+
+// setValue sets the node value. It returns true if the node had no value.
+func (trie *pathTrie[T]) setValue(value *T) bool {
 	// does node have an existing value?
-	isNewVal := node.value == nil
-	node.value = &value
+	isNewVal := trie.value == nil
+	trie.value = value
+	trie.clearTombstone()
+	if isNewVal && trie.config.insertionOrder != nil {
+		trie.config.insertionSeq++
+		trie.config.insertionOrder[trie] = trie.config.insertionSeq
+	}
+	if trie.config.versions != nil {
+		trie.config.version++
+		trie.config.versions[trie] = trie.config.version
+	}
+	if trie.config.dirty != nil {
+		trie.config.dirty[trie] = struct{}{}
+	}
 	return isNewVal
 }
 
+// clearValue removes the node value or tombstone, if any.
+func (trie *pathTrie[T]) clearValue() {
+	trie.clearTombstone()
+	if trie.value == nil {
+		return
+	}
+	trie.value = nil
+	if trie.config.insertionOrder != nil {
+		delete(trie.config.insertionOrder, trie)
+	}
+	if trie.config.versions != nil {
+		delete(trie.config.versions, trie)
+	}
+	if trie.config.accessCounts != nil {
+		delete(trie.config.accessCounts, trie)
+	}
+	if trie.config.putKeys != nil {
+		delete(trie.config.putKeys, trie)
+	}
+	if trie.config.dirty != nil {
+		delete(trie.config.dirty, trie)
+	}
+	if trie.config.suffixKeys != nil {
+		trie.unindexSuffix()
+	}
+	if trie.config.evictElems != nil {
+		trie.untrackEntry()
+	}
+}
+
+// clearChildren removes the node's descendants and their values. Returns
+// the number of values removed.
+func (trie *pathTrie[T]) clearChildren() int {
+	var count int
+	for _, child := range trie.childNodes() {
+		if child.value != nil {
+			count++
+		}
+		count += child.clearChildren()
+		child.clearValue()
+	}
+	trie.children = nil
+	return count
+}
+
+// putChild returns the child node for the given segment, creating it if it
+// does not exist.
+func (trie *pathTrie[T]) putChild(part string) *pathTrie[T] {
+	child := trie.child(part)
+	if child == nil {
+		if trie.children == nil {
+			trie.children = trie.config.newChildren()
+		}
+		child = trie.newPathTrieFromTrie()
+		trie.children.set(part, child)
+	}
+	return child
+}
+
+// Touch creates any missing nodes along the given key without setting a
+// value, so later Puts at or under the key do not allocate those nodes. The
+// key remains absent until a value is put.
+func (trie *pathTrie[T]) Touch(key string) {
+	key = trie.normalizeKey(key)
+	node := trie
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		node = node.putChild(part)
+	}
+}
+
+// Parent returns the nearest ancestor of the given key which holds a value,
+// along with its value. The key itself is never its own parent and need not
+// be present. Returns false if no ancestor holds a value.
+func (trie *pathTrie[T]) Parent(key string) (string, T, bool) {
+	key = trie.normalizeKey(key)
+	var parentKey string
+	var parent *T
+	node := trie
+	var matched string // key of the node, from its segments
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		if node.value != nil {
+			parentKey, parent = matched, node.value
+		}
+		if node = node.child(part); node == nil {
+			break
+		}
+		matched += part
+	}
+	if parent == nil {
+		return "", zeroValueOfT[T](), false
+	}
+	return parentKey, trie.readValue(*parent), true
+}
+
 // Delete removes the value associated with the given key. Returns true if a
 // node was found for the given key. If the node or any of its ancestors
 // becomes childless as a result, it is removed from the trie.
 func (trie *pathTrie[T]) Delete(key string) bool {
-	var path []nodeStr[T] // record ancestors to check later
+	key = trie.normalizeKey(key)
+	// probe for the node before recording a cleanup path
+	node := trie.nodeAt(key)
+	if node == nil {
+		// node does not exist
+		return false
+	}
+	// only a leaf node's ancestors may need cleanup
+	var path []nodeStr[T]
+	if node.isLeaf() && (!trie.config.noDeleteCleanup || trie.config.autoCompact) {
+		path = trie.ancestors(key)
+	}
+	node.deleteValue(path)
+	return true // node (internal or not) existed and its value was nil'd
+}
+
+// nodeAt returns the node at the given normalized key, or nil if there is
+// none.
+func (trie *pathTrie[T]) nodeAt(key string) *pathTrie[T] {
 	node := trie
-	for part, i := trie.segmenter(key, 0); part != ""; part, i = trie.segmenter(key, i) {
-		path = append(path, nodeStr[T]{part: part, node: node})
-		node = node.children[part]
-		if node == nil {
-			// node does not exist
-			return false
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		if node = node.child(part); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// DeleteAll removes the values associated with the given keys, as Delete
+// does for each key, but removes nodes left empty in a single pass over the
+// affected nodes rather than once per key. Returns the number of keys whose
+// value was removed.
+func (trie *pathTrie[T]) DeleteAll(keys []string) int {
+	if trie.config.noDeleteCleanup {
+		// there is no cleanup to merge, and Delete counts emptied nodes
+		var count int
+		for _, key := range keys {
+			if node := trie.nodeAt(trie.normalizeKey(key)); node != nil && node.value != nil {
+				count++
+			}
+			trie.Delete(key)
+		}
+		return count
+	}
+	sorted := make([]string, len(keys))
+	for i, key := range keys {
+		sorted[i] = trie.normalizeKey(key)
+	}
+	// sorting makes keys sharing their next part mostly adjacent
+	sort.Strings(sorted)
+	count, _ := trie.deleteAll(sorted, make([]int, len(sorted)))
+	return count
+}
+
+// deleteAll removes the values at the given keys, where starts holds the
+// start of the part of each key below the node. Adjacent keys sharing
+// their next part are deleted from the child in one descent, after which
+// the child is removed if it was left empty. Returns the number of values
+// removed and whether any of the keys exist.
+func (trie *pathTrie[T]) deleteAll(keys []string, starts []int) (int, bool) {
+	var count int
+	var found bool
+	for i := 0; i < len(keys); {
+		part, next := trie.config.segmenter(keys[i], starts[i])
+		if part == "" {
+			// the key ends at this node
+			if trie.value != nil {
+				count++
+			}
+			trie.clearValue()
+			found = true
+			i++
+			continue
+		}
+		starts[i] = next
+		j := i + 1
+		for ; j < len(keys); j++ {
+			nextPart, nextStart := trie.config.segmenter(keys[j], starts[j])
+			if nextPart != part {
+				break
+			}
+			starts[j] = nextStart
+		}
+		if child := trie.child(part); child != nil {
+			childCount, childFound := child.deleteAll(keys[i:j], starts[i:j])
+			count += childCount
+			found = found || childFound
+			if childFound && child.isLeaf() && child.value == nil && !child.isTombstone() {
+				trie.removeChild(part)
+			}
 		}
+		i = j
+	}
+	return count, found
+}
+
+// ancestors returns the ancestors of the existing node at the given key,
+// paired with the part leading to the next node along the key.
+func (trie *pathTrie[T]) ancestors(key string) []nodeStr[T] {
+	var path []nodeStr[T]
+	node := trie
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		path = append(path, nodeStr[T]{part: part, node: node})
+		node = node.child(part)
 	}
+	return path
+}
+
+// deleteValue deletes the node value. If the node becomes a childless leaf,
+// it is removed from its parent's children map, repeating for the ancestor
+// path recorded from the root to the node.
+func (trie *pathTrie[T]) deleteValue(path []nodeStr[T]) {
 	// delete the node value
-	node.value = nil
+	held := trie.value != nil || trie.isTombstone()
+	trie.clearValue()
+	if trie.config.noDeleteCleanup {
+		// only count nodes this delete emptied, not ones already empty
+		if trie.config.autoCompact && held && trie.isLeaf() {
+			trie.compactEmptied(path)
+		}
+		return
+	}
 	// if leaf, remove it from its parent's children map. Repeat for ancestor path.
-	if node.isLeaf() {
+	if trie.isLeaf() {
 		// iterate backwards over path
 		for i := len(path) - 1; i >= 0; i-- {
 			parent := path[i].node
 			part := path[i].part
-			delete(parent.children, part)
+			parent.removeChild(part)
 			if !parent.isLeaf() {
 				// parent has other children, stop
 				break
 			}
-			parent.children = nil
-			if parent.value != nil {
-				// parent has a value, stop
+			if parent.value != nil || parent.isTombstone() {
+				// parent has a value or tombstone, stop
 				break
 			}
 		}
 	}
-	return true // node (internal or not) existed and its value was nil'd
+}
+
+// Prune removes nodes which hold no value or tombstone and have no such
+// descendants, such as nodes left behind by Delete on a trie created
+// WithoutDeleteCleanup or by Touch. Returns the number of nodes removed.
+func (trie *pathTrie[T]) Prune() int {
+	trie.config.emptiedNodes = 0
+	return trie.prune()
+}
+
+func (trie *pathTrie[T]) prune() int {
+	var count int
+	for part, child := range trie.childNodes() {
+		count += child.prune()
+		if child.isLeaf() && child.value == nil && !child.isTombstone() {
+			trie.removeChild(part)
+			count++
+		}
+	}
+	return count
+}
+
+// ReplaceSubtree deletes all keys under the given prefix, including the
+// prefix itself, and puts each key/value of the replacement trie under the
+// prefix. The prefix is normalized like keys. Returns the net change in the
+// number of keys in the trie.
+func (trie *pathTrie[T]) ReplaceSubtree(prefix string, replacement Trie[T]) int {
+	removed := trie.deleteSubtree(prefix)
+	var added int
+	replacement.Walk(func(key string, value T) error {
+		if trie.Put(prefix+key, value) {
+			added++
+		}
+		return nil
+	})
+	return added - removed
+}
+
+// DeletePrefix deletes the key at the given prefix and all keys under it.
+// The prefix is normalized like keys and matches whole segments. Returns the
+// number of keys deleted. Use DryRunDeletePrefix to see which keys would be
+// deleted first.
+func (trie *pathTrie[T]) DeletePrefix(prefix string) int {
+	return trie.deleteSubtree(prefix)
+}
+
+// DryRunDeletePrefix returns the number of keys DeletePrefix would delete
+// for the given prefix and those keys, sorted, without modifying the trie,
+// such as to confirm a deletion before making it.
+func (trie *pathTrie[T]) DryRunDeletePrefix(prefix string) (count int, keys []string) {
+	return dryRunDeletePrefix[T](trie, prefix)
+}
+
+// deleteSubtree deletes the node at the given prefix and all of its
+// descendants. Returns the number of values deleted.
+func (trie *pathTrie[T]) deleteSubtree(prefix string) int {
+	prefix = trie.normalizeKey(prefix)
+	var path []nodeStr[T] // record ancestors to check later
+	node := trie
+	for part, i := trie.config.segmenter(prefix, 0); part != ""; part, i = trie.config.segmenter(prefix, i) {
+		path = append(path, nodeStr[T]{part: part, node: node})
+		node = node.child(part)
+		if node == nil {
+			return 0
+		}
+	}
+	count := node.clearChildren()
+	if node.value != nil {
+		count++
+	}
+	node.deleteValue(path)
+	return count
+}
+
+// GetPath returns the value stored at the key made up of the given segments,
+// bypassing the segmenter. Segments should be those the segmenter would
+// produce (e.g. "/a", "/b" for "/a/b").
+func (trie *pathTrie[T]) GetPath(segments []string) (T, bool) {
+	node := trie
+	for _, part := range segments {
+		node = node.child(part)
+		if node == nil {
+			return trie.loadPath(segments)
+		}
+	}
+	if node.value == nil {
+		if node.isTombstone() {
+			return trie.missValue(), false
+		}
+		return trie.loadPath(segments)
+	}
+	trie.countAccess(node)
+	return trie.readValue(*node.value), true
+}
+
+// loadPath calls the loader, if one is set, for a key given as segments.
+func (trie *pathTrie[T]) loadPath(segments []string) (T, bool) {
+	if trie.config.loader == nil {
+		return trie.missValue(), false
+	}
+	return trie.load(strings.Join(segments, ""))
+}
+
+// PutPath inserts the value into the trie at the key made up of the given
+// segments, bypassing the segmenter. See Put.
+func (trie *pathTrie[T]) PutPath(segments []string, value T) bool {
+	key := strings.Join(segments, "")
+	if trie.validate(key, value) != nil {
+		return false
+	}
+	node := trie
+	for _, part := range segments {
+		node = node.putChild(part)
+	}
+	return trie.putNode(key, segments, node, &value)
+}
+
+// DeletePath removes the value associated with the key made up of the given
+// segments, bypassing the segmenter. See Delete.
+func (trie *pathTrie[T]) DeletePath(segments []string) bool {
+	path := make([]nodeStr[T], 0, len(segments)) // record ancestors to check later
+	node := trie
+	for _, part := range segments {
+		path = append(path, nodeStr[T]{part: part, node: node})
+		node = node.child(part)
+		if node == nil {
+			// node does not exist
+			return false
+		}
+	}
+	node.deleteValue(path)
+	return true
 }
 
 // Walk iterates over each key/value stored in the trie and calls the given
 // walker function with the key and value. If the walker function returns
 // an error, the walk is aborted.
-// The traversal is depth first with no guaranteed order.
+// The traversal is depth first with no guaranteed order. The walker may put
+// or delete keys: keys put under nodes which have already been visited are
+// not walked, and deleted keys which have not been visited yet are skipped.
 func (trie *pathTrie[T]) Walk(walker WalkFunc[T]) error {
+	if trie == nil {
+		return nil
+	}
+	if trie.config.iterativeWalk {
+		return trie.walkIterative("", walker)
+	}
 	return trie.walk("", walker)
 }
 
-// WalkPath iterates over each key/value in the path in trie from the root to
-// the node at the given key, calling the given walker function for each
-// key/value. If the walker function returns an error, the walk is aborted.
-func (trie *pathTrie[T]) WalkPath(key string, walker WalkFunc[T]) error {
-	// Get root value if one exists.
+// WalkPostOrder iterates over each key/value stored in the trie and calls
+// the given walker function with the key and value, visiting all of a key's
+// descendants before the key itself. If the walker function returns an
+// error, the walk is aborted.
+// The traversal is depth first with no guaranteed order among siblings.
+func (trie *pathTrie[T]) WalkPostOrder(walker WalkFunc[T]) error {
+	return trie.walkPostOrder("", walker)
+}
+
+// WalkGrouped iterates over each node of the trie with immediate children
+// which hold values, and calls the given walker function once with the
+// node's key and those children in sorted order, such as to render a tree.
+// If the walker function returns an error, the walk is aborted.
+// The traversal is depth first, visiting parents before their children.
+func (trie *pathTrie[T]) WalkGrouped(walker GroupedWalkFunc[T]) error {
+	return trie.walkGrouped("", walker)
+}
+
+// WalkWithMetrics walks the trie like Walk and returns metrics about the
+// traversal, such as the number of nodes visited, alongside any error
+// returned by the walker. Metrics cover the nodes visited before an error.
+func (trie *pathTrie[T]) WalkWithMetrics(walker WalkFunc[T]) (WalkMetrics, error) {
+	var metrics WalkMetrics
+	err := trie.walkWithMetrics("", 0, walker, &metrics)
+	return metrics, err
+}
+
+// WalkFiltered iterates over each key/value stored in the trie and calls the
+// given walker function with the key and value, only descending into
+// children whose segment keep returns true for, given the segment and the
+// depth of the child, with top level segments at depth 1. Subtrees under a
+// rejected segment are skipped entirely. If the walker function returns an
+// error, the walk is aborted.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) WalkFiltered(keep func(segment string, depth int) bool, walker WalkFunc[T]) error {
+	return trie.walkFiltered("", 0, keep, walker)
+}
+
+func (trie *pathTrie[T]) walkFiltered(key string, depth int, keep func(segment string, depth int) bool, walker WalkFunc[T]) error {
+	children := trie.snapshotChildren()
 	if trie.value != nil {
-		if err := walker("", *trie.value); err != nil {
+		if err := walker(key, trie.readValue(*trie.value)); err != nil {
 			return err
 		}
 	}
-	for part, i := trie.segmenter(key, 0); ; part, i = trie.segmenter(key, i) {
-		if trie = trie.children[part]; trie == nil {
-			return nil
-		}
-		if trie.value != nil {
-			var k string
-			if i == -1 {
-				k = key
-			} else {
-				k = key[0:i]
-			}
-			if err := walker(k, *trie.value); err != nil {
-				return err
-			}
+	for _, child := range children {
+		if !keep(child.part, depth+1) {
+			continue
 		}
-		if i == -1 {
-			break
+		if err := child.node.walkFiltered(key+child.part, depth+1, keep, walker); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-// PathTrie node and the part string key of the child the path descends into.
-type nodeStr[T any] struct {
-	node *pathTrie[T]
-	part string
+// WalkLeaves iterates over each key/value stored in the trie whose node has
+// no descendants holding values and calls the given walker function with the
+// key and value, skipping values of internal nodes. If the walker function
+// returns an error, the walk is aborted.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) WalkLeaves(walker WalkFunc[T]) error {
+	_, err := trie.walkLeaves("", walker)
+	return err
 }
 
-func (trie *pathTrie[T]) walk(key string, walker WalkFunc[T]) error {
-	if trie.value != nil {
-		if err := walker(key, *trie.value); err != nil {
+// WalkDelete iterates over each key/value stored in the trie and calls the
+// given walker function with the key and value, deleting the key when the
+// walker returns true. Nodes left empty by deletes are removed as by Delete.
+// If the walker function returns an error, the walk is aborted after
+// deleting the current key if requested.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) WalkDelete(walker func(key string, value T) (delete bool, err error)) error {
+	_, err := trie.walkDelete("", walker)
+	return err
+}
+
+// WalkDeadline walks the trie like Walk, but aborts the walk and returns
+// ErrWalkDeadline if the deadline passes before the walk completes. The
+// deadline is checked before the walk and periodically as values are
+// walked.
+func (trie *pathTrie[T]) WalkDeadline(deadline time.Time, walker WalkFunc[T]) error {
+	return walkDeadline(trie.Walk, deadline, walker)
+}
+
+// WalkLimit walks the trie like Walk, but stops once the walker has been
+// called limit times, returning nil.
+func (trie *pathTrie[T]) WalkLimit(limit int, walker WalkFunc[T]) error {
+	return walkLimit(trie.Walk, limit, walker)
+}
+
+// WalkCollectErrors walks the trie like Walk, but rather than aborting when
+// the walker returns an error, it continues through the whole trie and
+// returns every error the walker returned, in walk order, such as for
+// validation passes. Returns nil if the walker returned no errors.
+func (trie *pathTrie[T]) WalkCollectErrors(walker WalkFunc[T]) []error {
+	return walkCollectErrors(trie.Walk, walker)
+}
+
+// WalkMutate iterates over each key/value stored in the trie and calls the
+// given walker function with the key and a pointer to the stored value, so
+// the walker may modify the value in place. If the walker function returns
+// an error, the walk is aborted.
+// Putting or deleting keys during the walk is unsafe.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) WalkMutate(walker func(key string, value *T) error) error {
+	return trie.walkMutate("", walker)
+}
+
+// WalkInsertionOrder iterates over each key/value stored in the trie in the
+// order keys were first inserted, calling the given walker function with the
+// key and value. If the walker function returns an error, the walk is
+// aborted. Returns ErrNoInsertionOrder if the trie was not created with
+// WithInsertionOrder.
+func (trie *pathTrie[T]) WalkInsertionOrder(walker WalkFunc[T]) error {
+	if trie.config.insertionOrder == nil {
+		return ErrNoInsertionOrder
+	}
+	type entry struct {
+		key  string
+		node *pathTrie[T]
+	}
+	entries := make([]entry, 0, len(trie.config.insertionOrder))
+	trie.walkNodes("", func(key string, node *pathTrie[T]) {
+		entries = append(entries, entry{key: key, node: node})
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return trie.config.insertionOrder[entries[i].node] < trie.config.insertionOrder[entries[j].node]
+	})
+	for _, e := range entries {
+		// skip values deleted by the walker
+		if e.node.value == nil {
+			continue
+		}
+		if err := walker(e.key, trie.readValue(*e.node.value)); err != nil {
 			return err
 		}
 	}
-	for part, child := range trie.children {
-		if err := child.walk(key+part, walker); err != nil {
+	return nil
+}
+
+// WalkPathRemainder walks the path from the root to the node at the given
+// key like WalkPath, also passing the walker the remainder of the key after
+// each matched key, such as for each handler of a middleware chain to know
+// what is left of a route. The remainder is empty at the key itself.
+func (trie *pathTrie[T]) WalkPathRemainder(key string, walker func(matchedKey string, value T, remainder string) error) error {
+	key = trie.normalizeKey(key)
+	node := trie
+	if node.value != nil {
+		if err := walker("", trie.readValue(*node.value), key); err != nil {
 			return err
 		}
 	}
+	var matched string // key of the node, from its segments
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		if node = node.child(part); node == nil {
+			return nil
+		}
+		matched += part
+		end := i
+		if i == -1 {
+			end = len(key)
+		}
+		if node.value != nil {
+			if err := walker(matched, trie.readValue(*node.value), key[end:]); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
+// WalkPath iterates over each key/value in the path in trie from the root to
+// the node at the given key, calling the given walker function for each
+// key/value. If the walker function returns an error, the walk is aborted.
+func (trie *pathTrie[T]) WalkPath(key string, walker WalkFunc[T]) error {
+	if trie == nil {
+		return nil
+	}
+	key = trie.normalizeKey(key)
+	// Get root value if one exists.
+	if trie.value != nil {
+		if err := walker("", trie.readValue(*trie.value)); err != nil {
+			return err
+		}
+	}
+	var matched string // key of the node, from its segments
+	for part, i := trie.config.segmenter(key, 0); ; part, i = trie.config.segmenter(key, i) {
+		if trie = trie.child(part); trie == nil {
+			return nil
+		}
+		matched += part
+		if trie.value != nil {
+			if err := walker(matched, trie.readValue(*trie.value)); err != nil {
+				return err
+			}
+		}
+		if i == -1 {
+			break
+		}
+	}
+	return nil
+}
+
+// WalkTree calls the walker with every node of the trie, including the root
+// and nodes without values, along with whether the node is the last child of
+// its parent and its depth in segments, such as to draw the trie as a tree. If
+// the walker function returns an error, the walk is aborted.
+// The traversal is depth first, visiting children in sorted order.
+func (trie *pathTrie[T]) WalkTree(walker TreeWalkFunc[T]) error {
+	return trie.walkTree("", true, 0, walker)
+}
+
+func (trie *pathTrie[T]) walkTree(key string, isLast bool, depth int, walker TreeWalkFunc[T]) error {
+	value := zeroValueOfT[T]()
+	if trie.value != nil {
+		value = trie.readValue(*trie.value)
+	}
+	if err := walker(key, value, isLast, depth); err != nil {
+		return err
+	}
+	parts := trie.sortedParts()
+	for i, part := range parts {
+		// skip children deleted by the walker
+		if child := trie.child(part); child != nil {
+			if err := child.walkTree(key+part, i == len(parts)-1, depth+1, walker); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sample returns a key/value stored in the trie, chosen uniformly at random
+// using rng, such that each stored key is equally likely. It walks the whole
+// trie, taking time proportional to its size. Returns false if the trie is
+// empty.
+func (trie *pathTrie[T]) Sample(rng *rand.Rand) (string, T, bool) {
+	return sample[T](trie, rng)
+}
+
+// KeysToDepth returns the sorted keys stored in the trie with a depth in
+// segments of at most depth, such as to load the top levels of a tree lazily.
+// The root key "" has depth 0. Nodes deeper than depth are not visited.
+func (trie *pathTrie[T]) KeysToDepth(depth int) []string {
+	var keys []string
+	trie.keysToDepth("", depth, &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+func (trie *pathTrie[T]) keysToDepth(key string, depth int, keys *[]string) {
+	if depth < 0 {
+		return
+	}
+	if trie.value != nil {
+		*keys = append(*keys, key)
+	}
+	for part, child := range trie.childNodes() {
+		child.keysToDepth(key+part, depth-1, keys)
+	}
+}
+
+// MinKey returns the smallest key stored in the trie. Keys are ordered by
+// segment, so "/a/b" sorts before "/a!" since segment "/a" sorts before
+// "/a!". It descends to the smallest child holding values at each node
+// rather than walking the trie. Returns false if the trie is empty.
+func (trie *pathTrie[T]) MinKey() (string, bool) {
+	key, node := trie.minNode()
+	return key, node != nil
+}
+
+// minNode returns the smallest key in the subtree, relative to the node, and
+// the node holding its value. Returns a nil node if the subtree is empty.
+func (trie *pathTrie[T]) minNode() (string, *pathTrie[T]) {
+	var key string
+	node := trie
+	for node.value == nil {
+		var next *pathTrie[T]
+		for _, part := range node.sortedParts() {
+			if child := node.child(part); child.hasValue() {
+				key += part
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return "", nil
+		}
+		node = next
+	}
+	return key, node
+}
+
+// NearestByPrefix returns the key/value stored in the trie whose key shares
+// the longest prefix of whole segments with the given key, such as for "did you
+// mean" suggestions. The key need not be a prefix of the match, or the match
+// of the key. Ties are broken by the smallest key, ordering keys as MinKey
+// does. Returns false if the trie is empty.
+func (trie *pathTrie[T]) NearestByPrefix(key string) (string, T, bool) {
+	key = trie.normalizeKey(key)
+	nodes := []*pathTrie[T]{trie}
+	prefixes := []string{""}
+	node, prefix := trie, ""
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		if node = node.child(part); node == nil {
+			break
+		}
+		prefix += part
+		nodes = append(nodes, node)
+		prefixes = append(prefixes, prefix)
+	}
+	// back off from the deepest matching node to the nearest which has
+	// values in its subtree
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if rest, match := nodes[i].minNode(); match != nil {
+			return prefixes[i] + rest, trie.readValue(*match.value), true
+		}
+	}
+	return "", zeroValueOfT[T](), false
+}
+
+// MaxKey returns the largest key stored in the trie, ordering keys as
+// MinKey does. It descends to the largest child holding values at each node
+// rather than walking the trie. Returns false if the trie is empty.
+func (trie *pathTrie[T]) MaxKey() (string, bool) {
+	var key string
+	node := trie
+	for {
+		parts := node.sortedParts()
+		var next *pathTrie[T]
+		for i := len(parts) - 1; i >= 0; i-- {
+			if child := node.child(parts[i]); child.hasValue() {
+				key += parts[i]
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return key, node.value != nil
+		}
+		node = next
+	}
+}
+
+// WalkWithChildCount iterates over each key/value stored in the trie and
+// calls the given walker function with the key, value, and the number of
+// children of the key's node, such as to tell whether a UI can expand it.
+// If the walker function returns an error, the walk is aborted.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) WalkWithChildCount(walker func(key string, value T, childCount int) error) error {
+	return trie.walkWithChildCount("", walker)
+}
+
+func (trie *pathTrie[T]) walkWithChildCount(key string, walker func(key string, value T, childCount int) error) error {
+	children := trie.snapshotChildren()
+	if trie.value != nil {
+		if err := walker(key, trie.readValue(*trie.value), len(children)); err != nil {
+			return err
+		}
+	}
+	for _, child := range children {
+		if err := child.node.walkWithChildCount(key+child.part, walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rekey returns a new trie holding each key/value stored in the trie at the
+// key the transform returns for its key, such as to add a prefix to every
+// key. The new trie segments and normalizes keys like the trie. If the
+// transform maps several keys to the same key, the value of the largest of
+// those keys wins.
+func (trie *pathTrie[T]) Rekey(transform func(oldKey string) string) CommonTrie[T] {
+	return rekey[T](trie, trie.newEmptyTrie(), transform)
+}
+
+// Ancestors returns the key/value of each node from the root to the given
+// key, inclusive, which holds a value, in order from the root, such as for
+// breadcrumbs or to find every prefix of a key which applies to it, like
+// stacked policies. It is like WalkPath, but returns the key/values.
+func (trie *pathTrie[T]) Ancestors(key string) []KeyValue[T] {
+	var ancestors []KeyValue[T]
+	trie.WalkPath(key, func(key string, value T) error {
+		ancestors = append(ancestors, KeyValue[T]{Key: key, Value: value})
+		return nil
+	})
+	return ancestors
+}
+
+// Explain describes how a lookup of the given key descends the trie, such
+// as where it stops when Get misses, for debugging. The message is meant for
+// people and its format may change.
+func (trie *pathTrie[T]) Explain(key string) string {
+	key = trie.normalizeKey(key)
+	node := trie
+	var matched string // key of the matched parts, from their segments
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		if node = node.child(part); node == nil {
+			if matched == "" {
+				return fmt.Sprintf("no child %q of the root", part)
+			}
+			return fmt.Sprintf("matched up to %q, no child %q", matched, part)
+		}
+		matched += part
+	}
+	switch {
+	case node.value != nil:
+		return fmt.Sprintf("found a value at %q", matched)
+	case node.isTombstone():
+		return fmt.Sprintf("matched %q, but it holds a tombstone", matched)
+	}
+	return fmt.Sprintf("matched %q, but it holds no value", matched)
+}
+
+// WalkPathReport reports how far the given key descends into the trie, as
+// WalkPath would. It returns the leading segments of the key which match
+// nodes, whether or not they hold values, and the remainder of the key
+// from the first segment which does not match. The remainder is empty if
+// the whole key matches.
+func (trie *pathTrie[T]) WalkPathReport(key string) (matchedSegments []string, unmatched string) {
+	key = trie.normalizeKey(key)
+	node := trie
+	start := 0 // start of the current part
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		if node = node.child(part); node == nil {
+			return matchedSegments, key[start:]
+		}
+		matchedSegments = append(matchedSegments, part)
+		start = i
+	}
+	return matchedSegments, ""
+}
+
+// LongestPrefixSegments returns the value stored at the longest prefix of
+// the given key which holds a value, which may be the key itself, along
+// with the segments of that prefix as the segmenter returned them. Joined,
+// the segments are the key Walk reports for the prefix, which may differ
+// from the leading part of the given key if the segmenter transforms
+// segments. Returns false if no prefix of the key holds a value.
+func (trie *pathTrie[T]) LongestPrefixSegments(key string) (segments []string, value T, ok bool) {
+	key = trie.normalizeKey(key)
+	node := trie
+	matched := -1 // number of segments of the longest valued prefix
+	if node.value != nil {
+		matched, value = 0, trie.readValue(*node.value)
+	}
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		if node = node.child(part); node == nil {
+			break
+		}
+		segments = append(segments, part)
+		if node.value != nil {
+			matched, value = len(segments), trie.readValue(*node.value)
+		}
+	}
+	if matched < 0 {
+		return nil, value, false
+	}
+	return segments[:matched:matched], value, true
+}
+
+// WalkSegments iterates over each key/value stored in the trie and calls the
+// given walker function with the segments of the key and the value. If the
+// walker function returns an error, the walk is aborted.
+// The segments slice is reused between calls to avoid allocations, so the
+// walker must copy it to retain it after returning.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) WalkSegments(walker SegmentsWalkFunc[T]) error {
+	segments := make([]string, 0, walkSegmentsDepth)
+	return trie.walkSegments(&segments, 0, walker)
+}
+
+// walkSegmentsDepth is the initial capacity of the segments buffer shared by
+// a WalkSegments walk, which only grows for deeper keys.
+const walkSegmentsDepth = 16
+
+// NestedMapValueKey is the reserved key holding a node's value in the maps
+// returned by ToNestedMap. Segmenters never produce empty segments, so it
+// cannot collide with a child segment.
+const NestedMapValueKey = ""
+
+// ToNestedMap returns the trie as nested maps mirroring its structure. Each
+// node becomes a map from its child segments to their maps, and a node's
+// value, if any, is stored under NestedMapValueKey, so internal nodes and
+// tombstones have no such entry. For example, keys "/a"
+// and "/a/b" become {"/a": {"": a, "/b": {"": b}}}.
+func (trie *pathTrie[T]) ToNestedMap() map[string]any {
+	m := make(map[string]any, trie.numChildren()+1)
+	if trie.value != nil {
+		m[NestedMapValueKey] = trie.readValue(*trie.value)
+	}
+	for part, child := range trie.childNodes() {
+		m[part] = child.ToNestedMap()
+	}
+	return m
+}
+
+// PrefixSeq returns an iterator over each key/value stored in the trie under
+// the given prefix, including the prefix itself, for use with range. The
+// prefix matches whole segments. The subtree is traversed lazily, so breaking
+// out of the range stops the traversal.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) PrefixSeq(prefix string) iter.Seq2[string, T] {
+	prefix = trie.normalizeKey(prefix)
+	return func(yield func(string, T) bool) {
+		node := trie
+		for part, i := trie.config.segmenter(prefix, 0); part != ""; part, i = trie.config.segmenter(prefix, i) {
+			node = node.child(part)
+			if node == nil {
+				return
+			}
+		}
+		node.seq(prefix, yield)
+	}
+}
+
+// Find returns the first key/value stored in the trie for which pred returns
+// true, stopping the traversal at the match. Returns false if no key/value
+// matches. The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) Find(pred func(key string, value T) bool) (string, T, bool) {
+	for key, value := range trie.PrefixSeq("") {
+		if pred(key, value) {
+			return key, value, true
+		}
+	}
+	return "", zeroValueOfT[T](), false
+}
+
+// LoadLines reads r line by line and puts the key/value parsed from each
+// line into the trie. Lines for which parse returns false are skipped.
+// Returns the number of entries put and any error reading r.
+func (trie *pathTrie[T]) LoadLines(r io.Reader, parse func(line string) (key string, value T, ok bool)) (int, error) {
+	return loadLines[T](trie, r, parse)
+}
+
+// WalkRange iterates in sorted order over each key/value stored in the trie
+// with a key k such that lo <= k < hi, calling the given walker function for
+// each key/value. Keys are ordered segment by segment (e.g. "/a/b" sorts
+// before "/a-b"). The bounds are normalized like keys. Subtrees outside the
+// range are not visited. If the walker function returns an error, the walk
+// is aborted.
+func (trie *pathTrie[T]) WalkRange(lo, hi string, walker WalkFunc[T]) error {
+	lo, hi = trie.normalizeKey(lo), trie.normalizeKey(hi)
+	return trie.walkRange("", trie.segments(lo), trie.segments(hi), true, true, false, walker)
+}
+
+// WalkBetweenPrefixes iterates in sorted order over each key/value stored in
+// the trie with a key k such that fromPrefix <= k and either k <= toPrefix or
+// toPrefix is a prefix of k, calling the given walker function for each
+// key/value. That is, the walk runs from fromPrefix through toPrefix and all
+// keys under it. The prefixes are normalized like keys. Subtrees outside the
+// range are not visited. If the walker function returns an error, the walk
+// is aborted.
+func (trie *pathTrie[T]) WalkBetweenPrefixes(fromPrefix, toPrefix string, walker WalkFunc[T]) error {
+	fromPrefix, toPrefix = trie.normalizeKey(fromPrefix), trie.normalizeKey(toPrefix)
+	return trie.walkRange("", trie.segments(fromPrefix), trie.segments(toPrefix), true, true, true, walker)
+}
+
+// DeepestKey returns a stored key at the maximum depth in the trie and its
+// depth in segments. If several keys tie for the maximum depth, any one may be
+// returned. Returns "" and 0 if the trie is empty.
+func (trie *pathTrie[T]) DeepestKey() (string, int) {
+	key, depth, _ := trie.deepestKey("", 0)
+	return key, depth
+}
+
+// NumInternalNodes returns the number of nodes, other than the root, which
+// hold no value and only branch to their children.
+func (trie *pathTrie[T]) NumInternalNodes() int {
+	var count int
+	for _, child := range trie.childNodes() {
+		if child.value == nil {
+			count++
+		}
+		count += child.NumInternalNodes()
+	}
+	return count
+}
+
+// BranchingHistogram returns the number of nodes with each number of
+// children, counting the root and every other node with children, such as
+// to tell whether the trie is bushy or chain-like.
+func (trie *pathTrie[T]) BranchingHistogram() map[int]int {
+	histogram := make(map[int]int)
+	trie.branchingHistogram(histogram)
+	return histogram
+}
+
+func (trie *pathTrie[T]) branchingHistogram(histogram map[int]int) {
+	if n := trie.numChildren(); n > 0 {
+		histogram[n]++
+	}
+	for _, child := range trie.childNodes() {
+		child.branchingHistogram(histogram)
+	}
+}
+
+// IsEmpty returns true if the trie holds no values. Internal nodes without
+// values, such as those created by Touch, do not count.
+func (trie *pathTrie[T]) IsEmpty() bool {
+	if trie == nil {
+		return true
+	}
+	return !trie.hasValue()
+}
+
+// hasValue returns true if the node or any descendant holds a value.
+func (trie *pathTrie[T]) hasValue() bool {
+	if trie.value != nil {
+		return true
+	}
+	for _, child := range trie.childNodes() {
+		if child.hasValue() {
+			return true
+		}
+	}
+	return false
+}
+
+// TopLevelCount returns the number of distinct first segments of keys in the
+// trie (i.e. the number of children of the root).
+func (trie *pathTrie[T]) TopLevelCount() int {
+	if trie == nil {
+		return 0
+	}
+	return trie.numChildren()
+}
+
+// ChildrenKeys returns the full keys of the immediate children of the node
+// at the given key, one segment longer than the key, in sorted order.
+// Children may be internal nodes without a value. Returns nil if the node
+// is a leaf or does not exist.
+func (trie *pathTrie[T]) ChildrenKeys(key string) []string {
+	key = trie.normalizeKey(key)
+	node := trie
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		if node = node.child(part); node == nil {
+			return nil
+		}
+	}
+	if node.isLeaf() {
+		return nil
+	}
+	keys := node.sortedParts()
+	for i, part := range keys {
+		keys[i] = key + part
+	}
+	return keys
+}
+
+// CommonPrefix returns the longest key prefix, aligned to whole segments, that
+// is shared by every key in the trie. Returns "" if the trie is empty or if
+// keys diverge at the root.
+func (trie *pathTrie[T]) CommonPrefix() string {
+	var prefix string
+	node := trie
+	for node.value == nil && node.numChildren() == 1 {
+		for part, child := range node.childNodes() {
+			prefix += part
+			node = child
+		}
+	}
+	return prefix
+}
+
+// PathTrie node and the part string key of the child the path descends into.
+type nodeStr[T any] struct {
+	node *pathTrie[T]
+	part string
+}
+
+func (trie *pathTrie[T]) walk(key string, walker WalkFunc[T]) error {
+	// snapshot children before calling the walker so a walker which puts or
+	// deletes keys does not modify the map being iterated
+	children := trie.snapshotChildren()
+	if trie.config.walkSeed != nil {
+		trie.shuffleChildren(key, children)
+	}
+	if trie.value != nil {
+		if err := walker(key, trie.readValue(*trie.value)); err != nil {
+			return err
+		}
+	}
+	for _, child := range children {
+		if err := child.node.walk(key+child.part, walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotChildren returns the node's children and their keys.
+func (trie *pathTrie[T]) snapshotChildren() []nodeStr[T] {
+	children := make([]nodeStr[T], 0, trie.numChildren())
+	for part, child := range trie.childNodes() {
+		children = append(children, nodeStr[T]{node: child, part: part})
+	}
+	return children
+}
+
+func (trie *pathTrie[T]) walkSegments(segments *[]string, depth int, walker SegmentsWalkFunc[T]) error {
+	if trie.value != nil {
+		if err := walker((*segments)[:depth], trie.readValue(*trie.value)); err != nil {
+			return err
+		}
+	}
+	for part, child := range trie.childNodes() {
+		// siblings overwrite the same element of the shared buffer
+		*segments = append((*segments)[:depth], part)
+		if err := child.walkSegments(segments, depth+1, walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (trie *pathTrie[T]) walkPostOrder(key string, walker WalkFunc[T]) error {
+	for part, child := range trie.childNodes() {
+		if err := child.walkPostOrder(key+part, walker); err != nil {
+			return err
+		}
+	}
+	if trie.value != nil {
+		return walker(key, trie.readValue(*trie.value))
+	}
+	return nil
+}
+
+func (trie *pathTrie[T]) walkGrouped(key string, walker GroupedWalkFunc[T]) error {
+	if trie.isLeaf() {
+		return nil
+	}
+	parts := trie.sortedParts()
+	var children []KeyValue[T]
+	for _, part := range parts {
+		if child := trie.child(part); child.value != nil {
+			children = append(children, KeyValue[T]{Key: key + part, Value: trie.readValue(*child.value)})
+		}
+	}
+	if len(children) > 0 {
+		if err := walker(key, children); err != nil {
+			return err
+		}
+	}
+	for _, part := range parts {
+		// the walker may have deleted the child
+		if child := trie.child(part); child != nil {
+			if err := child.walkGrouped(key+part, walker); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (trie *pathTrie[T]) walkWithMetrics(key string, depth int, walker WalkFunc[T], metrics *WalkMetrics) error {
+	metrics.NodesVisited++
+	metrics.MaxDepth = max(metrics.MaxDepth, depth)
+	children := trie.snapshotChildren()
+	if trie.value != nil {
+		metrics.ValuesDelivered++
+		if err := walker(key, trie.readValue(*trie.value)); err != nil {
+			return err
+		}
+	}
+	for _, child := range children {
+		if err := child.node.walkWithMetrics(key+child.part, depth+1, walker, metrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkLeaves walks the leaf values of the subtree and returns whether the
+// subtree holds any values.
+func (trie *pathTrie[T]) walkLeaves(key string, walker WalkFunc[T]) (bool, error) {
+	var hasValue bool
+	for _, child := range trie.snapshotChildren() {
+		childHasValue, err := child.node.walkLeaves(key+child.part, walker)
+		if err != nil {
+			return false, err
+		}
+		hasValue = hasValue || childHasValue
+	}
+	if trie.value == nil {
+		return hasValue, nil
+	}
+	if !hasValue {
+		if err := walker(key, trie.readValue(*trie.value)); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// walkDelete walks the subtree, deleting values as the walker requests, and
+// returns whether any values were deleted.
+func (trie *pathTrie[T]) walkDelete(key string, walker func(key string, value T) (bool, error)) (bool, error) {
+	children := trie.snapshotChildren()
+	var deleted bool
+	if trie.value != nil {
+		del, err := walker(key, trie.readValue(*trie.value))
+		if del {
+			trie.clearValue()
+			deleted = true
+		}
+		if err != nil {
+			return deleted, err
+		}
+	}
+	var err error
+	for _, child := range children {
+		var childDeleted bool
+		childDeleted, err = child.node.walkDelete(key+child.part, walker)
+		deleted = deleted || childDeleted
+		// remove children left empty by deletes
+		if !trie.config.noDeleteCleanup && childDeleted && child.node.isLeaf() && child.node.value == nil && !child.node.isTombstone() && trie.child(child.part) == child.node {
+			trie.removeChild(child.part)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return deleted, err
+}
+
+func (trie *pathTrie[T]) walkMutate(key string, walker func(key string, value *T) error) error {
+	if trie.value != nil {
+		if err := walker(key, trie.value); err != nil {
+			return err
+		}
+	}
+	for part, child := range trie.childNodes() {
+		if err := child.walkMutate(key+part, walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deepestKey returns the deepest stored key in the subtree at the given key
+// and depth, and whether the subtree stores any key.
+func (trie *pathTrie[T]) deepestKey(key string, depth int) (string, int, bool) {
+	deepest, maxDepth, found := key, depth, trie.value != nil
+	for part, child := range trie.childNodes() {
+		childKey, childDepth, ok := child.deepestKey(key+part, depth+1)
+		if ok && (!found || childDepth > maxDepth) {
+			deepest, maxDepth, found = childKey, childDepth, true
+		}
+	}
+	if !found {
+		return "", 0, false
+	}
+	return deepest, maxDepth, true
+}
+
+// walkNodes calls fn with the key of each node in the subtree which holds a
+// value.
+func (trie *pathTrie[T]) walkNodes(key string, fn func(key string, node *pathTrie[T])) {
+	if trie.value != nil {
+		fn(key, trie)
+	}
+	for part, child := range trie.childNodes() {
+		child.walkNodes(key+part, fn)
+	}
+}
+
+// seq yields each key/value in the subtree and returns false if yield
+// requested the iteration stop.
+func (trie *pathTrie[T]) seq(key string, yield func(string, T) bool) bool {
+	if trie.value != nil && !yield(key, trie.readValue(*trie.value)) {
+		return false
+	}
+	for part, child := range trie.childNodes() {
+		if !child.seq(key+part, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// walkRange walks the subtree in sorted order, bounded by the remaining
+// segments of lo and hi while the key so far equals their prefix (trackLo,
+// trackHi).
+// If hiSubtree is true, hi and its descendants are in range.
+func (trie *pathTrie[T]) walkRange(key string, lo, hi []string, trackLo, trackHi, hiSubtree bool, walker WalkFunc[T]) error {
+	if trackHi && len(hi) == 0 {
+		if !hiSubtree {
+			// key equals hi, so it and its descendants are out of range
+			return nil
+		}
+		// key equals hi, so it and its descendants are in range
+		trackHi = false
+	}
+	// a key which is a proper prefix of lo is out of range
+	if trie.value != nil && !(trackLo && len(lo) > 0) {
+		if err := walker(key, trie.readValue(*trie.value)); err != nil {
+			return err
+		}
+	}
+	for _, part := range trie.sortedParts() {
+		var childLo, childHi []string
+		childTrackLo, childTrackHi := false, false
+		if trackLo && len(lo) > 0 {
+			if part < lo[0] {
+				continue
+			}
+			childLo, childTrackLo = lo[1:], part == lo[0]
+		}
+		if trackHi {
+			if part > hi[0] {
+				break
+			}
+			childHi, childTrackHi = hi[1:], part == hi[0]
+		}
+		// skip children deleted by the walker
+		child := trie.child(part)
+		if child == nil {
+			continue
+		}
+		if err := child.walkRange(key+part, childLo, childHi, childTrackLo, childTrackHi, hiSubtree, walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// segments returns the segments of the key.
+func (trie *pathTrie[T]) segments(key string) []string {
+	var segments []string
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		segments = append(segments, part)
+	}
+	return segments
+}
+
+// sortedParts returns the segments of the node's children in sorted order.
+func (trie *pathTrie[T]) sortedParts() []string {
+	parts := make([]string, 0, trie.numChildren())
+	for part := range trie.childNodes() {
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	return parts
+}
+
+// numValues returns the number of values stored in the subtree.
+func (trie *pathTrie[T]) numValues() int {
+	var count int
+	if trie.value != nil {
+		count++
+	}
+	for _, child := range trie.childNodes() {
+		count += child.numValues()
+	}
+	return count
+}
+
 func (trie *pathTrie[T]) isLeaf() bool {
-	return len(trie.children) == 0
+	return trie.numChildren() == 0
 }
@@ -0,0 +1,205 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// The read-only trie format is a byte-wise trie. Data starts with the magic
+// bytes and the uint32 offset of the root node, followed by the nodes, each
+// written after its children. A node is a flags byte, a uvarint length
+// prefixed value if readOnlyHasValue is set, a uvarint count of children,
+// and a table of children sorted by key byte, each entry being the byte and
+// the uint32 offset of the child node. Integers are big endian.
+const (
+	readOnlyMagic     = "TRO1"
+	readOnlyHeaderLen = len(readOnlyMagic) + 4
+	readOnlyEntryLen  = 1 + 4
+	readOnlyHasValue  = 1 << 0
+)
+
+var (
+	// ErrInvalidReadOnlyTrie is returned when data is not in the read-only
+	// trie format.
+	ErrInvalidReadOnlyTrie = errors.New("trie: invalid read-only trie data")
+	// ErrReadOnlyTrieTooLarge is returned when a trie is too large to encode
+	// in the read-only trie format, which uses 32-bit offsets.
+	ErrReadOnlyTrieTooLarge = errors.New("trie: read-only trie too large")
+)
+
+// ReadOnlyTrie is an immutable trie which reads its nodes directly from a
+// byte slice in the format written by BuildReadOnlyTrie, such as a memory
+// mapped file, without decoding it onto the heap. Keys are looked up byte by
+// byte and values are the encoded bytes given to BuildReadOnlyTrie.
+// ReadOnlyTrie is safe for concurrent use.
+type ReadOnlyTrie struct {
+	data []byte
+	root int
+}
+
+// NewReadOnlyTrie returns a ReadOnlyTrie reading from data, which must not
+// be modified while the trie is in use. Returns ErrInvalidReadOnlyTrie if
+// data does not start with a read-only trie header. Lookups in corrupt data
+// report keys as missing rather than panicking.
+func NewReadOnlyTrie(data []byte) (*ReadOnlyTrie, error) {
+	if len(data) < readOnlyHeaderLen || string(data[:len(readOnlyMagic)]) != readOnlyMagic {
+		return nil, ErrInvalidReadOnlyTrie
+	}
+	root := int(binary.BigEndian.Uint32(data[len(readOnlyMagic):]))
+	if root < readOnlyHeaderLen || root >= len(data) {
+		return nil, ErrInvalidReadOnlyTrie
+	}
+	return &ReadOnlyTrie{data: data, root: root}, nil
+}
+
+// Get returns the value stored at the given key. The value aliases the
+// trie's data and must not be modified.
+func (t *ReadOnlyTrie) Get(key string) ([]byte, bool) {
+	node := t.root
+	for i := 0; i < len(key); i++ {
+		if node = t.child(node, key[i]); node < 0 {
+			return nil, false
+		}
+	}
+	return t.value(node)
+}
+
+// LongestPrefix returns the value stored at the longest prefix of the given
+// key, which may be the key itself, along with the prefix. The value aliases
+// the trie's data and must not be modified. Returns false if no prefix of
+// the key holds a value.
+func (t *ReadOnlyTrie) LongestPrefix(key string) (string, []byte, bool) {
+	var match []byte
+	matchLen := -1
+	node := t.root
+	for i := 0; ; i++ {
+		if value, ok := t.value(node); ok {
+			match, matchLen = value, i
+		}
+		if i == len(key) {
+			break
+		}
+		if node = t.child(node, key[i]); node < 0 {
+			break
+		}
+	}
+	if matchLen < 0 {
+		return "", nil, false
+	}
+	return key[:matchLen], match, true
+}
+
+// value returns the value of the node at the offset, if it has one.
+func (t *ReadOnlyTrie) value(node int) ([]byte, bool) {
+	if node >= len(t.data) || t.data[node]&readOnlyHasValue == 0 {
+		return nil, false
+	}
+	value, _, err := readBinaryField(t.data[node+1:])
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// child returns the offset of the child of the node at the offset for the
+// key byte, or -1 if there is none.
+func (t *ReadOnlyTrie) child(node int, b byte) int {
+	if node >= len(t.data) {
+		return -1
+	}
+	pos := node + 1
+	if t.data[node]&readOnlyHasValue != 0 {
+		_, rest, err := readBinaryField(t.data[pos:])
+		if err != nil {
+			return -1
+		}
+		pos = len(t.data) - len(rest)
+	}
+	count, size := binary.Uvarint(t.data[pos:])
+	if size <= 0 || count > uint64(len(t.data)-pos-size)/readOnlyEntryLen {
+		return -1
+	}
+	table := t.data[pos+size : pos+size+int(count)*readOnlyEntryLen]
+	i := sort.Search(int(count), func(i int) bool {
+		return table[i*readOnlyEntryLen] >= b
+	})
+	if i == int(count) || table[i*readOnlyEntryLen] != b {
+		return -1
+	}
+	entry := table[i*readOnlyEntryLen+1:]
+	return int(binary.BigEndian.Uint32(entry))
+}
+
+// BuildReadOnlyTrie encodes the key/values stored in the trie in the format
+// read by ReadOnlyTrie, encoding each value with the given function.
+// Returns ErrReadOnlyTrieTooLarge if the encoding exceeds 4 GiB.
+func BuildReadOnlyTrie[T any](trie Trie[T], encode func(T) []byte) ([]byte, error) {
+	var entries []readOnlyEntry
+	trie.Walk(func(key string, value T) error {
+		entries = append(entries, readOnlyEntry{key: key, value: encode(value)})
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	data := bytes.NewBufferString(readOnlyMagic)
+	data.Write(make([]byte, 4)) // root offset, set below
+	root := writeReadOnlyNode(data, entries, 0)
+	if data.Len() > math.MaxUint32 {
+		return nil, ErrReadOnlyTrieTooLarge
+	}
+	out := data.Bytes()
+	binary.BigEndian.PutUint32(out[len(readOnlyMagic):], uint32(root))
+	return out, nil
+}
+
+// readOnlyEntry is a key and its encoded value.
+type readOnlyEntry struct {
+	key   string
+	value []byte
+}
+
+// writeReadOnlyNode writes the node for the sorted entries, which share
+// their first depth bytes, after writing its children. Returns the offset
+// of the node.
+func writeReadOnlyNode(data *bytes.Buffer, entries []readOnlyEntry, depth int) int {
+	var value []byte
+	hasValue := len(entries) > 0 && len(entries[0].key) == depth
+	if hasValue {
+		value = entries[0].value
+		entries = entries[1:]
+	}
+	type childEntry struct {
+		b      byte
+		offset int
+	}
+	var children []childEntry
+	for i := 0; i < len(entries); {
+		b := entries[i].key[depth]
+		j := i + 1
+		for j < len(entries) && entries[j].key[depth] == b {
+			j++
+		}
+		children = append(children, childEntry{b: b, offset: writeReadOnlyNode(data, entries[i:j], depth+1)})
+		i = j
+	}
+
+	offset := data.Len()
+	var flags byte
+	if hasValue {
+		flags |= readOnlyHasValue
+	}
+	data.WriteByte(flags)
+	if hasValue {
+		data.Write(binary.AppendUvarint(nil, uint64(len(value))))
+		data.Write(value)
+	}
+	data.Write(binary.AppendUvarint(nil, uint64(len(children))))
+	for _, child := range children {
+		data.WriteByte(child.b)
+		data.Write(binary.BigEndian.AppendUint32(nil, uint32(child.offset)))
+	}
+	return offset
+}
@@ -0,0 +1,99 @@
+package trie
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestReadOnlyTrie(t *testing.T) {
+	source := NewPathTrie[int]()
+	keys := []string{"", "/a", "/a/b", "/a/b/c", "/ab", "/b/c", "這是", "\x00\xff"}
+	for i, key := range keys {
+		source.Put(key, i)
+	}
+	data, err := BuildReadOnlyTrie[int](source, func(value int) []byte {
+		return []byte(strconv.Itoa(value))
+	})
+	if err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	trie, err := NewReadOnlyTrie(data)
+	if err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	for i, key := range keys {
+		if value, ok := trie.Get(key); !ok || string(value) != strconv.Itoa(i) {
+			t.Errorf("expected key %q to have value %d, got %q", key, i, value)
+		}
+	}
+	for _, key := range []string{"/", "/a/", "/a/b/c/d", "/c", "這"} {
+		if value, ok := trie.Get(key); ok {
+			t.Errorf("expected key %q to be missing, got %q", key, value)
+		}
+	}
+
+	cases := []struct {
+		key    string
+		prefix string
+		value  string
+	}{
+		{"/a/b/c/d", "/a/b/c", "3"},
+		{"/a/bc", "/a/b", "2"},
+		{"/ab", "/ab", "4"},
+		{"/b/d", "", "0"},
+		{"這是一", "這是", "6"},
+	}
+	for _, c := range cases {
+		prefix, value, ok := trie.LongestPrefix(c.key)
+		if !ok || prefix != c.prefix || string(value) != c.value {
+			t.Errorf("expected key %q to have longest prefix (%q, %s), got (%q, %q, %t)", c.key, c.prefix, c.value, prefix, value, ok)
+		}
+	}
+}
+
+func TestReadOnlyTrieEmpty(t *testing.T) {
+	data, err := BuildReadOnlyTrie(NewRuneTrie[string](), func(value string) []byte {
+		return []byte(value)
+	})
+	if err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	trie, err := NewReadOnlyTrie(data)
+	if err != nil {
+		t.Fatalf("expected error nil, got %v", err)
+	}
+	if value, ok := trie.Get(""); ok {
+		t.Errorf("expected empty trie to have no values, got %q", value)
+	}
+	if prefix, value, ok := trie.LongestPrefix("/a"); ok {
+		t.Errorf("expected no longest prefix, got (%q, %q)", prefix, value)
+	}
+}
+
+func TestReadOnlyTrieInvalid(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("TRO"), []byte("nope\x00\x00\x00\x08\x00\x00"), []byte("TRO1\x00\x00\x00\x40\x00")} {
+		if _, err := NewReadOnlyTrie(data); !errors.Is(err, ErrInvalidReadOnlyTrie) {
+			t.Errorf("expected ErrInvalidReadOnlyTrie for %q, got %v", data, err)
+		}
+	}
+
+	// lookups in truncated data do not panic
+	source := NewRuneTrie[string]()
+	for _, key := range []string{"a", "ab", "abc", "b"} {
+		source.Put(key, key)
+	}
+	data, _ := BuildReadOnlyTrie(source, func(value string) []byte {
+		return []byte(value)
+	})
+	root := int(data[len(readOnlyMagic)+3])
+	for n := root + 1; n < len(data); n++ {
+		truncated := append([]byte(nil), data[:n]...)
+		trie, err := NewReadOnlyTrie(truncated)
+		if err != nil {
+			continue
+		}
+		trie.Get("abc")
+		trie.LongestPrefix("abcd")
+	}
+}
@@ -0,0 +1,35 @@
+package trie
+
+// Router dispatches paths to handlers registered for path prefixes. A path
+// is handled by the handler of the longest registered pattern which is a
+// segment-wise prefix of the path (e.g. "/api" matches "/api/users" but not
+// "/apis"). It is built on a path trie of handlers.
+type Router[H any] struct {
+	trie PathTrie[H]
+}
+
+// NewRouter allocates and returns a new Router. Options configure the
+// underlying path trie (e.g. WithKeyNormalizer to ignore case).
+func NewRouter[H any](opts ...PathTrieOption[H]) *Router[H] {
+	return &Router[H]{
+		trie: NewPathTrie(opts...),
+	}
+}
+
+// Handle registers the handler for the pattern, replacing any handler
+// already registered for it. The pattern "" matches every path.
+func (r *Router[H]) Handle(pattern string, h H) {
+	r.trie.Put(pattern, h)
+}
+
+// Lookup returns the handler registered for the longest pattern matching
+// the path. Returns false if no pattern matches.
+func (r *Router[H]) Lookup(path string) (H, bool) {
+	var handler H
+	var found bool
+	r.trie.WalkPath(path, func(pattern string, h H) error {
+		handler, found = h, true
+		return nil
+	})
+	return handler, found
+}
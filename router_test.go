@@ -0,0 +1,47 @@
+package trie
+
+import "testing"
+
+func TestRouter(t *testing.T) {
+	router := NewRouter[string]()
+	if h, ok := router.Lookup("/api"); ok {
+		t.Errorf("expected no handler for /api, got %s", h)
+	}
+	router.Handle("/api", "api")
+	router.Handle("/api/users", "users")
+	router.Handle("/api/users/admin/settings", "settings")
+	router.Handle("/static", "static")
+
+	cases := map[string]string{
+		"/api":                           "api",
+		"/api/posts":                     "api",
+		"/api/users":                     "users",
+		"/api/users/42":                  "users",
+		"/api/users/admin":               "users",
+		"/api/users/admin/settings":      "settings",
+		"/api/users/admin/settings/mail": "settings",
+		"/static/app.js":                 "static",
+	}
+	for path, expected := range cases {
+		if h, ok := router.Lookup(path); !ok || h != expected {
+			t.Errorf("expected path %s to route to %s, got %s", path, expected, h)
+		}
+	}
+	// patterns match whole segments
+	for _, path := range []string{"/apis", "/", "/other/api"} {
+		if h, ok := router.Lookup(path); ok {
+			t.Errorf("expected no handler for %s, got %s", path, h)
+		}
+	}
+
+	// a catch all handles unmatched paths
+	router.Handle("", "default")
+	if h, ok := router.Lookup("/apis"); !ok || h != "default" {
+		t.Errorf("expected path /apis to route to default, got %s", h)
+	}
+	// re-registering replaces the handler
+	router.Handle("/api", "api2")
+	if h, ok := router.Lookup("/api/posts"); !ok || h != "api2" {
+		t.Errorf("expected path /api/posts to route to api2, got %s", h)
+	}
+}
@@ -1,19 +1,75 @@
 package trie
 
+import (
+	"fmt"
+	"io"
+	"iter"
+	"math/rand/v2"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
 // runeTrie is a trie of runes with string keys and generic type values.
 type runeTrie[T any] struct {
+	config   *runeTrieConfig // shared by all nodes of the trie
 	value    *T
 	children map[rune]*runeTrie[T]
 }
 
+// runeTrieConfig holds the configuration of a rune trie.
+type runeTrieConfig struct {
+	normalize func(key string) string
+}
+
+// RuneTrieOption is an optional configuration option for a rune trie.
+type RuneTrieOption[T any] func(*runeTrie[T])
+
+// WithUnicodeNormalization normalizes keys passed to Get, Put, Delete,
+// WalkPath, and Touch, and the prefixes, bounds, and queries passed to other
+// methods, to the given Unicode normalization form (e.g. norm.NFC) using
+// golang.org/x/text/unicode/norm, so that equivalent keys such as "é" and
+// "e\u0301" are the same key. Keys are stored normalized, so Walks return
+// normalized keys.
+func WithUnicodeNormalization[T any](form norm.Form) RuneTrieOption[T] {
+	return func(trie *runeTrie[T]) {
+		trie.config.normalize = func(key string) string {
+			if form.IsNormalString(key) {
+				return key
+			}
+			return form.String(key)
+		}
+	}
+}
+
 // NewRuneTrie allocates and returns a new rune implementation of Trie.
-func NewRuneTrie[T any]() Trie[T] {
-	return new(runeTrie[T])
+func NewRuneTrie[T any](opts ...RuneTrieOption[T]) RuneTrie[T] {
+	trie := &runeTrie[T]{
+		config: &runeTrieConfig{},
+	}
+	for _, opt := range opts {
+		opt(trie)
+	}
+	return trie
+}
+
+// normalizeKey normalizes the key if the trie has a key normalizer.
+func (trie *runeTrie[T]) normalizeKey(key string) string {
+	if trie.config.normalize == nil {
+		return key
+	}
+	return trie.config.normalize(key)
 }
 
 // Get returns the value stored at the given key. Returns nil for internal
 // nodes or for nodes with a value of nil.
 func (trie *runeTrie[T]) Get(key string) (T, bool) {
+	if trie == nil {
+		return zeroValueOfT[T](), false
+	}
+	key = trie.normalizeKey(key)
 	node := trie
 	for _, r := range key {
 		node = node.children[r]
@@ -27,23 +83,27 @@ func (trie *runeTrie[T]) Get(key string) (T, bool) {
 	return *node.value, true
 }
 
+// GetWithDepth returns the value stored at the given key, as Get does, along
+// with the depth of the key in runes. Returns a depth of 0 if the key is
+// missing.
+func (trie *runeTrie[T]) GetWithDepth(key string) (T, int, bool) {
+	value, ok := trie.Get(key)
+	if !ok {
+		return value, 0, false
+	}
+	return value, utf8.RuneCountInString(trie.normalizeKey(key)), true
+}
+
 // Put inserts the value into the trie at the given key, replacing any
 // existing items. It returns true if the put adds a new value, false
 // if it replaces an existing value.
 // Note that internal nodes have nil values so a stored nil value will not
 // be distinguishable and will not be included in Walks.
 func (trie *runeTrie[T]) Put(key string, value T) bool {
+	key = trie.normalizeKey(key)
 	node := trie
 	for _, r := range key {
-		child := node.children[r]
-		if child == nil {
-			if node.children == nil {
-				node.children = map[rune]*runeTrie[T]{}
-			}
-			child = new(runeTrie[T])
-			node.children[r] = child
-		}
-		node = child
+		node = node.putChild(r)
 	}
 	// does node have an existing value?
 	isNewVal := node.value == nil
@@ -51,30 +111,160 @@ func (trie *runeTrie[T]) Put(key string, value T) bool {
 	return isNewVal
 }
 
+// PutRef inserts the value pointer into the trie at the given key, replacing
+// any existing items, without copying the value. The trie shares the
+// pointed-to value with the caller, so later changes through the pointer
+// are visible to Gets and Walks. A nil value is ignored. It returns true if
+// the put adds a new value, false if it replaces an existing value.
+func (trie *runeTrie[T]) PutRef(key string, value *T) bool {
+	if value == nil {
+		return false
+	}
+	key = trie.normalizeKey(key)
+	node := trie
+	for _, r := range key {
+		node = node.putChild(r)
+	}
+	// does node have an existing value?
+	isNewVal := node.value == nil
+	node.value = value
+	return isNewVal
+}
+
+// putChild returns the child node for the given rune, creating it if it does
+// not exist.
+func (trie *runeTrie[T]) putChild(r rune) *runeTrie[T] {
+	child := trie.children[r]
+	if child == nil {
+		if trie.children == nil {
+			trie.children = map[rune]*runeTrie[T]{}
+		}
+		child = &runeTrie[T]{config: trie.config}
+		trie.children[r] = child
+	}
+	return child
+}
+
+// Touch creates any missing nodes along the given key without setting a
+// value, so later Puts at or under the key do not allocate those nodes. The
+// key remains absent until a value is put.
+func (trie *runeTrie[T]) Touch(key string) {
+	key = trie.normalizeKey(key)
+	node := trie
+	for _, r := range key {
+		node = node.putChild(r)
+	}
+}
+
+// Parent returns the nearest ancestor of the given key which holds a value,
+// along with its value. The key itself is never its own parent and need not
+// be present. Returns false if no ancestor holds a value.
+func (trie *runeTrie[T]) Parent(key string) (string, T, bool) {
+	key = trie.normalizeKey(key)
+	var parentKey string
+	var parent *T
+	node := trie
+	for i, r := range key {
+		if node.value != nil {
+			parentKey, parent = key[:i], node.value
+		}
+		if node = node.children[r]; node == nil {
+			break
+		}
+	}
+	if parent == nil {
+		return "", zeroValueOfT[T](), false
+	}
+	return parentKey, *parent, true
+}
+
 // Delete removes the value associated with the given key. Returns true if a
 // node was found for the given key. If the node or any of its ancestors
 // becomes childless as a result, it is removed from the trie.
 func (trie *runeTrie[T]) Delete(key string) bool {
-	path := make([]nodeRune[T], len(key)) // record ancestors to check later
+	key = trie.normalizeKey(key)
+	path := make([]nodeRune[T], 0, len(key)) // record ancestors to check later
 	node := trie
-	for i, r := range key {
-		path[i] = nodeRune[T]{r: r, node: node}
+	for _, r := range key {
+		path = append(path, nodeRune[T]{r: r, node: node})
 		node = node.children[r]
 		if node == nil {
 			// node does not exist
 			return false
 		}
 	}
+	node.deleteValue(path)
+	return true // node (internal or not) existed and its value was nil'd
+}
+
+// DeleteAll removes the values associated with the given keys, as Delete
+// does for each key, but removes nodes left empty in a single pass over the
+// affected nodes rather than once per key. Returns the number of keys whose
+// value was removed.
+func (trie *runeTrie[T]) DeleteAll(keys []string) int {
+	sorted := make([]string, len(keys))
+	for i, key := range keys {
+		sorted[i] = trie.normalizeKey(key)
+	}
+	// sorting makes keys sharing their next rune adjacent
+	sort.Strings(sorted)
+	count, _ := trie.deleteAll(sorted, 0)
+	return count
+}
+
+// deleteAll removes the values at the given keys, which share the first
+// offset bytes leading to the node. Keys sharing their next rune are
+// deleted from the child in one descent, after which the child is removed
+// if it was left empty. Returns the number of values removed and whether
+// any of the keys exist.
+func (trie *runeTrie[T]) deleteAll(keys []string, offset int) (int, bool) {
+	var count int
+	var found bool
+	for i := 0; i < len(keys); {
+		if len(keys[i]) == offset {
+			// the key ends at this node
+			if trie.value != nil {
+				count++
+			}
+			trie.value = nil
+			found = true
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(keys[i][offset:])
+		j := i + 1
+		for ; j < len(keys) && len(keys[j]) > offset; j++ {
+			if next, _ := utf8.DecodeRuneInString(keys[j][offset:]); next != r {
+				break
+			}
+		}
+		if child := trie.children[r]; child != nil {
+			childCount, childFound := child.deleteAll(keys[i:j], offset+size)
+			count += childCount
+			found = found || childFound
+			if childFound && child.isLeaf() && child.value == nil {
+				delete(trie.children, r)
+				if trie.isLeaf() {
+					trie.children = nil
+				}
+			}
+		}
+		i = j
+	}
+	return count, found
+}
+
+// deleteValue deletes the node value. If the node becomes a childless leaf,
+// it is removed from its parent's children map, repeating for the ancestor
+// path recorded from the root to the node.
+func (trie *runeTrie[T]) deleteValue(path []nodeRune[T]) {
 	// delete the node value
-	node.value = nil
+	trie.value = nil
 	// if leaf, remove it from its parent's children map. Repeat for ancestor
 	// path.
-	if node.isLeaf() {
+	if trie.isLeaf() {
 		// iterate backwards over path
-		for i := len(key) - 1; i >= 0; i-- {
-			if path[i].node == nil {
-				continue
-			}
+		for i := len(path) - 1; i >= 0; i-- {
 			parent := path[i].node
 			r := path[i].r
 			delete(parent.children, r)
@@ -89,21 +279,186 @@ func (trie *runeTrie[T]) Delete(key string) bool {
 			}
 		}
 	}
-	return true // node (internal or not) existed and its value was nil'd
+}
+
+// ReplaceSubtree deletes all keys under the given prefix, including the
+// prefix itself, and puts each key/value of the replacement trie under the
+// prefix. The prefix is normalized like keys. Returns the net change in the
+// number of keys in the trie.
+func (trie *runeTrie[T]) ReplaceSubtree(prefix string, replacement Trie[T]) int {
+	removed := trie.deleteSubtree(prefix)
+	var added int
+	replacement.Walk(func(key string, value T) error {
+		if trie.Put(prefix+key, value) {
+			added++
+		}
+		return nil
+	})
+	return added - removed
+}
+
+// DeletePrefix deletes the key at the given prefix and all keys under it.
+// The prefix is normalized like keys and matches whole runes. Returns the
+// number of keys deleted. Use DryRunDeletePrefix to see which keys would be
+// deleted first.
+func (trie *runeTrie[T]) DeletePrefix(prefix string) int {
+	return trie.deleteSubtree(prefix)
+}
+
+// DryRunDeletePrefix returns the number of keys DeletePrefix would delete
+// for the given prefix and those keys, sorted, without modifying the trie,
+// such as to confirm a deletion before making it.
+func (trie *runeTrie[T]) DryRunDeletePrefix(prefix string) (count int, keys []string) {
+	return dryRunDeletePrefix[T](trie, prefix)
+}
+
+// deleteSubtree deletes the node at the given prefix and all of its
+// descendants. Returns the number of values deleted.
+func (trie *runeTrie[T]) deleteSubtree(prefix string) int {
+	prefix = trie.normalizeKey(prefix)
+	path := make([]nodeRune[T], 0, len(prefix)) // record ancestors to check later
+	node := trie
+	for _, r := range prefix {
+		path = append(path, nodeRune[T]{r: r, node: node})
+		node = node.children[r]
+		if node == nil {
+			return 0
+		}
+	}
+	count := node.numValues()
+	node.children = nil
+	node.deleteValue(path)
+	return count
 }
 
 // Walk iterates over each key/value stored in the trie and calls the given
 // walker function with the key and value. If the walker function returns
 // an error, the walk is aborted.
-// The traversal is depth first with no guaranteed order.
+// The traversal is depth first with no guaranteed order. The walker may put
+// or delete keys: keys put under nodes which have already been visited are
+// not walked, and deleted keys which have not been visited yet are skipped.
 func (trie *runeTrie[T]) Walk(walker WalkFunc[T]) error {
+	if trie == nil {
+		return nil
+	}
 	return trie.walk("", walker)
 }
 
+// WalkPostOrder iterates over each key/value stored in the trie and calls
+// the given walker function with the key and value, visiting all of a key's
+// descendants before the key itself. If the walker function returns an
+// error, the walk is aborted.
+// The traversal is depth first with no guaranteed order among siblings.
+func (trie *runeTrie[T]) WalkPostOrder(walker WalkFunc[T]) error {
+	return trie.walkPostOrder("", walker)
+}
+
+// WalkGrouped iterates over each node of the trie with immediate children
+// which hold values, and calls the given walker function once with the
+// node's key and those children in sorted order, such as to render a tree.
+// If the walker function returns an error, the walk is aborted.
+// The traversal is depth first, visiting parents before their children.
+func (trie *runeTrie[T]) WalkGrouped(walker GroupedWalkFunc[T]) error {
+	return trie.walkGrouped("", walker)
+}
+
+// WalkWithMetrics walks the trie like Walk and returns metrics about the
+// traversal, such as the number of nodes visited, alongside any error
+// returned by the walker. Metrics cover the nodes visited before an error.
+func (trie *runeTrie[T]) WalkWithMetrics(walker WalkFunc[T]) (WalkMetrics, error) {
+	var metrics WalkMetrics
+	err := trie.walkWithMetrics("", 0, walker, &metrics)
+	return metrics, err
+}
+
+// WalkLeaves iterates over each key/value stored in the trie whose node has
+// no descendants holding values and calls the given walker function with the
+// key and value, skipping values of internal nodes. If the walker function
+// returns an error, the walk is aborted.
+// The traversal is depth first with no guaranteed order.
+func (trie *runeTrie[T]) WalkLeaves(walker WalkFunc[T]) error {
+	_, err := trie.walkLeaves("", walker)
+	return err
+}
+
+// WalkDelete iterates over each key/value stored in the trie and calls the
+// given walker function with the key and value, deleting the key when the
+// walker returns true. Nodes left empty by deletes are removed as by Delete.
+// If the walker function returns an error, the walk is aborted after
+// deleting the current key if requested.
+// The traversal is depth first with no guaranteed order.
+func (trie *runeTrie[T]) WalkDelete(walker func(key string, value T) (delete bool, err error)) error {
+	_, err := trie.walkDelete("", walker)
+	return err
+}
+
+// WalkDeadline walks the trie like Walk, but aborts the walk and returns
+// ErrWalkDeadline if the deadline passes before the walk completes. The
+// deadline is checked before the walk and periodically as values are
+// walked.
+func (trie *runeTrie[T]) WalkDeadline(deadline time.Time, walker WalkFunc[T]) error {
+	return walkDeadline(trie.Walk, deadline, walker)
+}
+
+// WalkLimit walks the trie like Walk, but stops once the walker has been
+// called limit times, returning nil.
+func (trie *runeTrie[T]) WalkLimit(limit int, walker WalkFunc[T]) error {
+	return walkLimit(trie.Walk, limit, walker)
+}
+
+// WalkCollectErrors walks the trie like Walk, but rather than aborting when
+// the walker returns an error, it continues through the whole trie and
+// returns every error the walker returned, in walk order, such as for
+// validation passes. Returns nil if the walker returned no errors.
+func (trie *runeTrie[T]) WalkCollectErrors(walker WalkFunc[T]) []error {
+	return walkCollectErrors(trie.Walk, walker)
+}
+
+// WalkMutate iterates over each key/value stored in the trie and calls the
+// given walker function with the key and a pointer to the stored value, so
+// the walker may modify the value in place. If the walker function returns
+// an error, the walk is aborted.
+// Putting or deleting keys during the walk is unsafe.
+// The traversal is depth first with no guaranteed order.
+func (trie *runeTrie[T]) WalkMutate(walker func(key string, value *T) error) error {
+	return trie.walkMutate("", walker)
+}
+
+// WalkPathRemainder walks the path from the root to the node at the given
+// key like WalkPath, also passing the walker the remainder of the key after
+// each matched key, such as for each handler of a middleware chain to know
+// what is left of a route. The remainder is empty at the key itself.
+func (trie *runeTrie[T]) WalkPathRemainder(key string, walker func(matchedKey string, value T, remainder string) error) error {
+	key = trie.normalizeKey(key)
+	node := trie
+	if node.value != nil {
+		if err := walker("", *node.value, key); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if node = node.children[r]; node == nil {
+			return nil
+		}
+		i += size
+		if node.value != nil {
+			if err := walker(key[:i], *node.value, key[i:]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // WalkPath iterates over each key/value in the path in trie from the root to
 // the node at the given key, calling the given walker function for each
 // key/value. If the walker function returns an error, the walk is aborted.
 func (trie *runeTrie[T]) WalkPath(key string, walker WalkFunc[T]) error {
+	if trie == nil {
+		return nil
+	}
+	key = trie.normalizeKey(key)
 	// Get root value if one exists.
 	if trie.value != nil {
 		if err := walker("", *trie.value); err != nil {
@@ -111,12 +466,15 @@ func (trie *runeTrie[T]) WalkPath(key string, walker WalkFunc[T]) error {
 		}
 	}
 
-	for i, r := range key {
+	for i := 0; i < len(key); {
+		// slice keys after whole runes, which may be several bytes
+		r, size := utf8.DecodeRuneInString(key[i:])
 		if trie = trie.children[r]; trie == nil {
 			return nil
 		}
+		i += size
 		if trie.value != nil {
-			if err := walker(string(key[0:i+1]), *trie.value); err != nil {
+			if err := walker(key[:i], *trie.value); err != nil {
 				return err
 			}
 		}
@@ -124,6 +482,405 @@ func (trie *runeTrie[T]) WalkPath(key string, walker WalkFunc[T]) error {
 	return nil
 }
 
+// WalkTree calls the walker with every node of the trie, including the root
+// and nodes without values, along with whether the node is the last child of
+// its parent and its depth in runes, such as to draw the trie as a tree. If
+// the walker function returns an error, the walk is aborted.
+// The traversal is depth first, visiting children in sorted order.
+func (trie *runeTrie[T]) WalkTree(walker TreeWalkFunc[T]) error {
+	return trie.walkTree("", true, 0, walker)
+}
+
+func (trie *runeTrie[T]) walkTree(key string, isLast bool, depth int, walker TreeWalkFunc[T]) error {
+	value := zeroValueOfT[T]()
+	if trie.value != nil {
+		value = *trie.value
+	}
+	if err := walker(key, value, isLast, depth); err != nil {
+		return err
+	}
+	runes := trie.sortedRunes()
+	for i, r := range runes {
+		// skip children deleted by the walker
+		if child := trie.children[r]; child != nil {
+			if err := child.walkTree(key+string(r), i == len(runes)-1, depth+1, walker); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sample returns a key/value stored in the trie, chosen uniformly at random
+// using rng, such that each stored key is equally likely. It walks the whole
+// trie, taking time proportional to its size. Returns false if the trie is
+// empty.
+func (trie *runeTrie[T]) Sample(rng *rand.Rand) (string, T, bool) {
+	return sample[T](trie, rng)
+}
+
+// KeysToDepth returns the sorted keys stored in the trie with a depth in
+// runes of at most depth, such as to load the top levels of a tree lazily.
+// The root key "" has depth 0. Nodes deeper than depth are not visited.
+func (trie *runeTrie[T]) KeysToDepth(depth int) []string {
+	var keys []string
+	trie.keysToDepth("", depth, &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+func (trie *runeTrie[T]) keysToDepth(key string, depth int, keys *[]string) {
+	if depth < 0 {
+		return
+	}
+	if trie.value != nil {
+		*keys = append(*keys, key)
+	}
+	for r, child := range trie.children {
+		child.keysToDepth(key+string(r), depth-1, keys)
+	}
+}
+
+// MinKey returns the smallest key stored in the trie. Keys are ordered by
+// rune, which matches the byte order of valid UTF-8 keys. It descends to the
+// smallest child holding values at each node rather than walking the trie.
+// Returns false if the trie is empty.
+func (trie *runeTrie[T]) MinKey() (string, bool) {
+	key, node := trie.minNode()
+	return key, node != nil
+}
+
+// minNode returns the smallest key in the subtree, relative to the node, and
+// the node holding its value. Returns a nil node if the subtree is empty.
+func (trie *runeTrie[T]) minNode() (string, *runeTrie[T]) {
+	var key string
+	node := trie
+	for node.value == nil {
+		var next *runeTrie[T]
+		for _, r := range node.sortedRunes() {
+			if child := node.children[r]; child.hasValue() {
+				key += string(r)
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return "", nil
+		}
+		node = next
+	}
+	return key, node
+}
+
+// NearestByPrefix returns the key/value stored in the trie whose key shares
+// the longest prefix of whole runes with the given key, such as for "did you
+// mean" suggestions. The key need not be a prefix of the match, or the match
+// of the key. Ties are broken by the smallest key, ordering keys as MinKey
+// does. Returns false if the trie is empty.
+func (trie *runeTrie[T]) NearestByPrefix(key string) (string, T, bool) {
+	key = trie.normalizeKey(key)
+	nodes := []*runeTrie[T]{trie}
+	prefixes := []string{""}
+	node, prefix := trie, ""
+	for _, r := range key {
+		if node = node.children[r]; node == nil {
+			break
+		}
+		prefix += string(r)
+		nodes = append(nodes, node)
+		prefixes = append(prefixes, prefix)
+	}
+	// back off from the deepest matching node to the nearest which has
+	// values in its subtree
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if rest, match := nodes[i].minNode(); match != nil {
+			return prefixes[i] + rest, *match.value, true
+		}
+	}
+	return "", zeroValueOfT[T](), false
+}
+
+// MaxKey returns the largest key stored in the trie, ordering keys as
+// MinKey does. It descends to the largest child holding values at each node
+// rather than walking the trie. Returns false if the trie is empty.
+func (trie *runeTrie[T]) MaxKey() (string, bool) {
+	var key string
+	node := trie
+	for {
+		runes := node.sortedRunes()
+		var next *runeTrie[T]
+		for i := len(runes) - 1; i >= 0; i-- {
+			if child := node.children[runes[i]]; child.hasValue() {
+				key += string(runes[i])
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return key, node.value != nil
+		}
+		node = next
+	}
+}
+
+// WalkWithChildCount iterates over each key/value stored in the trie and
+// calls the given walker function with the key, value, and the number of
+// children of the key's node, such as to tell whether a UI can expand it.
+// If the walker function returns an error, the walk is aborted.
+// The traversal is depth first with no guaranteed order.
+func (trie *runeTrie[T]) WalkWithChildCount(walker func(key string, value T, childCount int) error) error {
+	return trie.walkWithChildCount("", walker)
+}
+
+func (trie *runeTrie[T]) walkWithChildCount(key string, walker func(key string, value T, childCount int) error) error {
+	children := trie.snapshotChildren()
+	if trie.value != nil {
+		if err := walker(key, *trie.value, len(children)); err != nil {
+			return err
+		}
+	}
+	for _, child := range children {
+		if err := child.node.walkWithChildCount(key+string(child.r), walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rekey returns a new trie holding each key/value stored in the trie at the
+// key the transform returns for its key, such as to add a prefix to every
+// key. The new trie normalizes keys like the trie. If the transform maps
+// several keys to the same key, the value of the largest of those keys
+// wins.
+func (trie *runeTrie[T]) Rekey(transform func(oldKey string) string) CommonTrie[T] {
+	return rekey[T](trie, &runeTrie[T]{config: trie.config}, transform)
+}
+
+// Ancestors returns the key/value of each node from the root to the given
+// key, inclusive, which holds a value, in order from the root, such as for
+// breadcrumbs or to find every prefix of a key which applies to it, like
+// stacked policies. It is like WalkPath, but returns the key/values.
+func (trie *runeTrie[T]) Ancestors(key string) []KeyValue[T] {
+	var ancestors []KeyValue[T]
+	trie.WalkPath(key, func(key string, value T) error {
+		ancestors = append(ancestors, KeyValue[T]{Key: key, Value: value})
+		return nil
+	})
+	return ancestors
+}
+
+// Explain describes how a lookup of the given key descends the trie, such
+// as where it stops when Get misses, for debugging. The message is meant for
+// people and its format may change.
+func (trie *runeTrie[T]) Explain(key string) string {
+	key = trie.normalizeKey(key)
+	node := trie
+	for i, r := range key {
+		if node = node.children[r]; node == nil {
+			if i == 0 {
+				return fmt.Sprintf("no child %q of the root", r)
+			}
+			return fmt.Sprintf("matched up to %q, no child %q", key[:i], r)
+		}
+	}
+	if node.value == nil {
+		return fmt.Sprintf("matched %q, but it holds no value", key)
+	}
+	return fmt.Sprintf("found a value at %q", key)
+}
+
+// FuzzySearch returns the sorted keys within a Levenshtein distance of
+// maxDist of the query, counting rune insertions, deletions, and
+// substitutions. Each node computes one row of the edit distance matrix from
+// its parent's row, and subtrees are pruned once every entry of a row
+// exceeds maxDist.
+func (trie *runeTrie[T]) FuzzySearch(query string, maxDist int) []string {
+	if maxDist < 0 {
+		return nil
+	}
+	q := []rune(trie.normalizeKey(query))
+	// distances from the empty key to each prefix of the query
+	row := make([]int, len(q)+1)
+	for i := range row {
+		row[i] = i
+	}
+	var keys []string
+	if trie.value != nil && row[len(q)] <= maxDist {
+		keys = append(keys, "")
+	}
+	for r, child := range trie.children {
+		child.fuzzySearch(string(r), r, q, row, maxDist, &keys)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PrefixSeq returns an iterator over each key/value stored in the trie under
+// the given prefix, including the prefix itself, for use with range. The
+// prefix matches whole runes. The subtree is traversed lazily, so breaking
+// out of the range stops the traversal.
+// The traversal is depth first with no guaranteed order.
+func (trie *runeTrie[T]) PrefixSeq(prefix string) iter.Seq2[string, T] {
+	prefix = trie.normalizeKey(prefix)
+	return func(yield func(string, T) bool) {
+		node := trie
+		for _, r := range prefix {
+			node = node.children[r]
+			if node == nil {
+				return
+			}
+		}
+		node.seq(prefix, yield)
+	}
+}
+
+// Find returns the first key/value stored in the trie for which pred returns
+// true, stopping the traversal at the match. Returns false if no key/value
+// matches. The traversal is depth first with no guaranteed order.
+func (trie *runeTrie[T]) Find(pred func(key string, value T) bool) (string, T, bool) {
+	for key, value := range trie.PrefixSeq("") {
+		if pred(key, value) {
+			return key, value, true
+		}
+	}
+	return "", zeroValueOfT[T](), false
+}
+
+// LoadLines reads r line by line and puts the key/value parsed from each
+// line into the trie. Lines for which parse returns false are skipped.
+// Returns the number of entries put and any error reading r.
+func (trie *runeTrie[T]) LoadLines(r io.Reader, parse func(line string) (key string, value T, ok bool)) (int, error) {
+	return loadLines[T](trie, r, parse)
+}
+
+// WalkRange iterates in sorted order over each key/value stored in the trie
+// with a key k such that lo <= k < hi, calling the given walker function for
+// each key/value. Subtrees outside the range are not visited. If the walker
+// function returns an error, the walk is aborted.
+func (trie *runeTrie[T]) WalkRange(lo, hi string, walker WalkFunc[T]) error {
+	lo, hi = trie.normalizeKey(lo), trie.normalizeKey(hi)
+	return trie.walkRange("", []rune(lo), []rune(hi), true, true, false, walker)
+}
+
+// WalkBetweenPrefixes iterates in sorted order over each key/value stored in
+// the trie with a key k such that fromPrefix <= k and either k <= toPrefix or
+// toPrefix is a prefix of k, calling the given walker function for each
+// key/value. That is, the walk runs from fromPrefix through toPrefix and all
+// keys under it. Subtrees outside the range are not visited. If the walker
+// function returns an error, the walk is aborted.
+func (trie *runeTrie[T]) WalkBetweenPrefixes(fromPrefix, toPrefix string, walker WalkFunc[T]) error {
+	fromPrefix, toPrefix = trie.normalizeKey(fromPrefix), trie.normalizeKey(toPrefix)
+	return trie.walkRange("", []rune(fromPrefix), []rune(toPrefix), true, true, true, walker)
+}
+
+// DeepestKey returns a stored key at the maximum depth in the trie and its
+// depth in runes. If several keys tie for the maximum depth, any one may be
+// returned. Returns "" and 0 if the trie is empty.
+func (trie *runeTrie[T]) DeepestKey() (string, int) {
+	key, depth, _ := trie.deepestKey("", 0)
+	return key, depth
+}
+
+// NumInternalNodes returns the number of nodes, other than the root, which
+// hold no value and only branch to their children.
+func (trie *runeTrie[T]) NumInternalNodes() int {
+	var count int
+	for _, child := range trie.children {
+		if child.value == nil {
+			count++
+		}
+		count += child.NumInternalNodes()
+	}
+	return count
+}
+
+// BranchingHistogram returns the number of nodes with each number of
+// children, counting the root and every other node with children, such as
+// to tell whether the trie is bushy or chain-like.
+func (trie *runeTrie[T]) BranchingHistogram() map[int]int {
+	histogram := make(map[int]int)
+	trie.branchingHistogram(histogram)
+	return histogram
+}
+
+func (trie *runeTrie[T]) branchingHistogram(histogram map[int]int) {
+	if n := len(trie.children); n > 0 {
+		histogram[n]++
+	}
+	for _, child := range trie.children {
+		child.branchingHistogram(histogram)
+	}
+}
+
+// IsEmpty returns true if the trie holds no values. Internal nodes without
+// values, such as those created by Touch, do not count.
+func (trie *runeTrie[T]) IsEmpty() bool {
+	if trie == nil {
+		return true
+	}
+	return !trie.hasValue()
+}
+
+// hasValue returns true if the node or any descendant holds a value.
+func (trie *runeTrie[T]) hasValue() bool {
+	if trie.value != nil {
+		return true
+	}
+	for _, child := range trie.children {
+		if child.hasValue() {
+			return true
+		}
+	}
+	return false
+}
+
+// TopLevelCount returns the number of distinct first runes of keys in the
+// trie (i.e. the number of children of the root).
+func (trie *runeTrie[T]) TopLevelCount() int {
+	if trie == nil {
+		return 0
+	}
+	return len(trie.children)
+}
+
+// ChildrenKeys returns the full keys of the immediate children of the node
+// at the given key, one rune longer than the key, in sorted order. Children
+// may be internal nodes without a value. Returns nil if the node is a leaf
+// or does not exist.
+func (trie *runeTrie[T]) ChildrenKeys(key string) []string {
+	key = trie.normalizeKey(key)
+	node := trie
+	for _, r := range key {
+		if node = node.children[r]; node == nil {
+			return nil
+		}
+	}
+	if node.isLeaf() {
+		return nil
+	}
+	runes := node.sortedRunes()
+	keys := make([]string, len(runes))
+	for i, r := range runes {
+		keys[i] = key + string(r)
+	}
+	return keys
+}
+
+// CommonPrefix returns the longest key prefix, aligned to whole runes, that
+// is shared by every key in the trie. Returns "" if the trie is empty or if
+// keys diverge at the root.
+func (trie *runeTrie[T]) CommonPrefix() string {
+	var prefix string
+	node := trie
+	for node.value == nil && len(node.children) == 1 {
+		for r, child := range node.children {
+			prefix += string(r)
+			node = child
+		}
+	}
+	return prefix
+}
+
 // RuneTrie node and the rune key of the child the path descends into.
 type nodeRune[T any] struct {
 	node *runeTrie[T]
@@ -131,19 +888,281 @@ type nodeRune[T any] struct {
 }
 
 func (trie *runeTrie[T]) walk(key string, walker WalkFunc[T]) error {
+	// snapshot children before calling the walker so a walker which puts or
+	// deletes keys does not modify the map being iterated
+	children := trie.snapshotChildren()
 	if trie.value != nil {
 		if err := walker(key, *trie.value); err != nil {
 			return err
 		}
 	}
+	for _, child := range children {
+		if err := child.node.walk(key+string(child.r), walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotChildren returns the node's children and their keys.
+func (trie *runeTrie[T]) snapshotChildren() []nodeRune[T] {
+	children := make([]nodeRune[T], 0, len(trie.children))
 	for r, child := range trie.children {
-		if err := child.walk(key+string(r), walker); err != nil {
+		children = append(children, nodeRune[T]{node: child, r: r})
+	}
+	return children
+}
+
+func (trie *runeTrie[T]) fuzzySearch(key string, r rune, query []rune, prevRow []int, maxDist int, keys *[]string) {
+	row := make([]int, len(prevRow))
+	row[0] = prevRow[0] + 1
+	rowMin := row[0]
+	for i := 1; i < len(row); i++ {
+		insertCost := row[i-1] + 1
+		deleteCost := prevRow[i] + 1
+		replaceCost := prevRow[i-1]
+		if query[i-1] != r {
+			replaceCost++
+		}
+		row[i] = minInt(insertCost, minInt(deleteCost, replaceCost))
+		rowMin = minInt(rowMin, row[i])
+	}
+	if trie.value != nil && row[len(query)] <= maxDist {
+		*keys = append(*keys, key)
+	}
+	// no descendant key can be within maxDist
+	if rowMin > maxDist {
+		return
+	}
+	for childRune, child := range trie.children {
+		child.fuzzySearch(key+string(childRune), childRune, query, row, maxDist, keys)
+	}
+}
+
+func (trie *runeTrie[T]) walkPostOrder(key string, walker WalkFunc[T]) error {
+	for r, child := range trie.children {
+		if err := child.walkPostOrder(key+string(r), walker); err != nil {
 			return err
 		}
 	}
+	if trie.value != nil {
+		return walker(key, *trie.value)
+	}
 	return nil
 }
 
+func (trie *runeTrie[T]) walkGrouped(key string, walker GroupedWalkFunc[T]) error {
+	if trie.isLeaf() {
+		return nil
+	}
+	runes := trie.sortedRunes()
+	var children []KeyValue[T]
+	for _, r := range runes {
+		if child := trie.children[r]; child.value != nil {
+			children = append(children, KeyValue[T]{Key: key + string(r), Value: *child.value})
+		}
+	}
+	if len(children) > 0 {
+		if err := walker(key, children); err != nil {
+			return err
+		}
+	}
+	for _, r := range runes {
+		// the walker may have deleted the child
+		if child := trie.children[r]; child != nil {
+			if err := child.walkGrouped(key+string(r), walker); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (trie *runeTrie[T]) walkWithMetrics(key string, depth int, walker WalkFunc[T], metrics *WalkMetrics) error {
+	metrics.NodesVisited++
+	metrics.MaxDepth = max(metrics.MaxDepth, depth)
+	children := trie.snapshotChildren()
+	if trie.value != nil {
+		metrics.ValuesDelivered++
+		if err := walker(key, *trie.value); err != nil {
+			return err
+		}
+	}
+	for _, child := range children {
+		if err := child.node.walkWithMetrics(key+string(child.r), depth+1, walker, metrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkLeaves walks the leaf values of the subtree and returns whether the
+// subtree holds any values.
+func (trie *runeTrie[T]) walkLeaves(key string, walker WalkFunc[T]) (bool, error) {
+	var hasValue bool
+	for _, child := range trie.snapshotChildren() {
+		childHasValue, err := child.node.walkLeaves(key+string(child.r), walker)
+		if err != nil {
+			return false, err
+		}
+		hasValue = hasValue || childHasValue
+	}
+	if trie.value == nil {
+		return hasValue, nil
+	}
+	if !hasValue {
+		if err := walker(key, *trie.value); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// walkDelete walks the subtree, deleting values as the walker requests, and
+// returns whether any values were deleted.
+func (trie *runeTrie[T]) walkDelete(key string, walker func(key string, value T) (bool, error)) (bool, error) {
+	children := trie.snapshotChildren()
+	var deleted bool
+	if trie.value != nil {
+		del, err := walker(key, *trie.value)
+		if del {
+			trie.value = nil
+			deleted = true
+		}
+		if err != nil {
+			return deleted, err
+		}
+	}
+	var err error
+	for _, child := range children {
+		var childDeleted bool
+		childDeleted, err = child.node.walkDelete(key+string(child.r), walker)
+		deleted = deleted || childDeleted
+		// remove children left empty by deletes
+		if childDeleted && child.node.isLeaf() && child.node.value == nil && trie.children[child.r] == child.node {
+			delete(trie.children, child.r)
+			if trie.isLeaf() {
+				trie.children = nil
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return deleted, err
+}
+
+func (trie *runeTrie[T]) walkMutate(key string, walker func(key string, value *T) error) error {
+	if trie.value != nil {
+		if err := walker(key, trie.value); err != nil {
+			return err
+		}
+	}
+	for r, child := range trie.children {
+		if err := child.walkMutate(key+string(r), walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deepestKey returns the deepest stored key in the subtree at the given key
+// and depth, and whether the subtree stores any key.
+func (trie *runeTrie[T]) deepestKey(key string, depth int) (string, int, bool) {
+	deepest, maxDepth, found := key, depth, trie.value != nil
+	for r, child := range trie.children {
+		childKey, childDepth, ok := child.deepestKey(key+string(r), depth+1)
+		if ok && (!found || childDepth > maxDepth) {
+			deepest, maxDepth, found = childKey, childDepth, true
+		}
+	}
+	if !found {
+		return "", 0, false
+	}
+	return deepest, maxDepth, true
+}
+
+// seq yields each key/value in the subtree and returns false if yield
+// requested the iteration stop.
+func (trie *runeTrie[T]) seq(key string, yield func(string, T) bool) bool {
+	if trie.value != nil && !yield(key, *trie.value) {
+		return false
+	}
+	for r, child := range trie.children {
+		if !child.seq(key+string(r), yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// walkRange walks the subtree in sorted order, bounded by the remaining runes
+// of lo and hi while the key so far equals their prefix (trackLo, trackHi).
+// If hiSubtree is true, hi and its descendants are in range.
+func (trie *runeTrie[T]) walkRange(key string, lo, hi []rune, trackLo, trackHi, hiSubtree bool, walker WalkFunc[T]) error {
+	if trackHi && len(hi) == 0 {
+		if !hiSubtree {
+			// key equals hi, so it and its descendants are out of range
+			return nil
+		}
+		// key equals hi, so it and its descendants are in range
+		trackHi = false
+	}
+	// a key which is a proper prefix of lo is out of range
+	if trie.value != nil && !(trackLo && len(lo) > 0) {
+		if err := walker(key, *trie.value); err != nil {
+			return err
+		}
+	}
+	for _, r := range trie.sortedRunes() {
+		var childLo, childHi []rune
+		childTrackLo, childTrackHi := false, false
+		if trackLo && len(lo) > 0 {
+			if r < lo[0] {
+				continue
+			}
+			childLo, childTrackLo = lo[1:], r == lo[0]
+		}
+		if trackHi {
+			if r > hi[0] {
+				break
+			}
+			childHi, childTrackHi = hi[1:], r == hi[0]
+		}
+		// skip children deleted by the walker
+		child := trie.children[r]
+		if child == nil {
+			continue
+		}
+		if err := child.walkRange(key+string(r), childLo, childHi, childTrackLo, childTrackHi, hiSubtree, walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedRunes returns the runes of the node's children in sorted order.
+func (trie *runeTrie[T]) sortedRunes() []rune {
+	runes := make([]rune, 0, len(trie.children))
+	for r := range trie.children {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes
+}
+
+// numValues returns the number of values stored in the subtree.
+func (trie *runeTrie[T]) numValues() int {
+	var count int
+	if trie.value != nil {
+		count++
+	}
+	for _, child := range trie.children {
+		count += child.numValues()
+	}
+	return count
+}
+
 func (trie *runeTrie[T]) isLeaf() bool {
 	return len(trie.children) == 0
 }
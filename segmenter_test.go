@@ -74,6 +74,30 @@ func TestPathSegmenterEdgeCases(t *testing.T) {
 	}
 }
 
+func TestCommonDepth(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		depth int
+	}{
+		{"", "", 0},
+		{"/a", "", 0},
+		{"/a/b", "/c/b", 0},
+		{"/a/b", "/ab", 0},
+		{"/a/b/c", "/a/b/d", 2},
+		{"/a/b", "/a/b/c", 2},
+		{"/a/bc", "/a/b", 1},
+		{"/a/b/c", "/a/b/c", 3},
+	}
+	for _, c := range cases {
+		if depth := CommonDepth(PathSegmenter, c.a, c.b); depth != c.depth {
+			t.Errorf("expected keys %s and %s to share %d segments, got %d", c.a, c.b, c.depth, depth)
+		}
+	}
+	if depth := CommonDepth(testPathSegmenterDot, "a.b.c", "a.b.d"); depth != 2 {
+		t.Errorf("expected keys a.b.c and a.b.d to share 2 segments, got %d", depth)
+	}
+}
+
 func testPathSegmenterDot(path string, start int) (segment string, next int) {
 	if len(path) == 0 || start < 0 || start > len(path)-1 {
 		return "", -1
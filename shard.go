@@ -0,0 +1,105 @@
+package trie
+
+import "sort"
+
+// assignShards assigns items with the given sizes to n shards, largest
+// first to the shard with the smallest total, and returns the shard of each
+// item.
+func assignShards(sizes []int, n int) []int {
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return sizes[order[i]] > sizes[order[j]] })
+	totals := make([]int, n)
+	shards := make([]int, len(sizes))
+	for _, item := range order {
+		smallest := 0
+		for shard := range totals {
+			if totals[shard] < totals[smallest] {
+				smallest = shard
+			}
+		}
+		shards[item] = smallest
+		totals[smallest] += sizes[item]
+	}
+	return shards
+}
+
+// Shard splits the trie into n independent tries, distributing the subtrees
+// under each top level segment so that the numbers of values are balanced.
+// A root value goes with the other values in the smallest shard. Together
+// the shards hold each key/value exactly once. Shards keep the segmenter
+// and key normalizer of the trie, but no other options. Returns nil if n is
+// less than 1.
+func (trie *pathTrie[T]) Shard(n int) []CommonTrie[T] {
+	if n < 1 {
+		return nil
+	}
+	shards := make([]*pathTrie[T], n)
+	result := make([]CommonTrie[T], n)
+	for i := range shards {
+		shards[i] = trie.newEmptyTrie()
+		result[i] = shards[i]
+	}
+	children := trie.snapshotChildren()
+	sizes := make([]int, len(children)+1)
+	for i, child := range children {
+		sizes[i] = child.node.numValues()
+	}
+	if trie.value != nil {
+		// the root value is the last item
+		sizes[len(children)] = 1
+	}
+	assigned := assignShards(sizes, n)
+	for i, child := range children {
+		shard := shards[assigned[i]]
+		child.node.walk(child.part, func(key string, value T) error {
+			shard.put(key, &value)
+			return nil
+		})
+	}
+	if trie.value != nil {
+		value := *trie.value
+		shards[assigned[len(children)]].put("", &value)
+	}
+	return result
+}
+
+// Shard splits the trie into n independent tries, distributing the subtrees
+// under each top level rune so that the numbers of values are balanced. A
+// root value goes with the other values in the smallest shard. Together the
+// shards hold each key/value exactly once. Shards keep the options of the
+// trie. Returns nil if n is less than 1.
+func (trie *runeTrie[T]) Shard(n int) []CommonTrie[T] {
+	if n < 1 {
+		return nil
+	}
+	shards := make([]*runeTrie[T], n)
+	result := make([]CommonTrie[T], n)
+	for i := range shards {
+		shards[i] = &runeTrie[T]{config: trie.config}
+		result[i] = shards[i]
+	}
+	children := trie.snapshotChildren()
+	sizes := make([]int, len(children)+1)
+	for i, child := range children {
+		sizes[i] = child.node.numValues()
+	}
+	if trie.value != nil {
+		// the root value is the last item
+		sizes[len(children)] = 1
+	}
+	assigned := assignShards(sizes, n)
+	for i, child := range children {
+		shard := shards[assigned[i]]
+		child.node.walk(string(child.r), func(key string, value T) error {
+			shard.Put(key, value)
+			return nil
+		})
+	}
+	if trie.value != nil {
+		shards[assigned[len(children)]].Put("", *trie.value)
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package trie
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShard(t *testing.T) {
+	for _, trie := range []CommonTrie[int]{NewRuneTrie[int](), NewPathTrie[int]()} {
+		table := map[string]int{"": 0}
+		// top level subtrees of 8, 4, 3, 2, 1, 1, and 1 values
+		for i, size := range []int{8, 4, 3, 2, 1, 1, 1} {
+			for j := 0; j < size; j++ {
+				table[fmt.Sprintf("%c/%d", 'a'+i, j)] = len(table)
+			}
+		}
+		for key, value := range table {
+			trie.Put(key, value)
+		}
+
+		shards := trie.Shard(3)
+		if len(shards) != 3 {
+			t.Fatalf("expected 3 shards, got %d", len(shards))
+		}
+		union := make(map[string]int)
+		var sizes []int
+		for _, shard := range shards {
+			var size int
+			shard.Walk(func(key string, value int) error {
+				if _, ok := union[key]; ok {
+					t.Errorf("expected key %s to be in one shard", key)
+				}
+				union[key] = value
+				size++
+				return nil
+			})
+			sizes = append(sizes, size)
+		}
+		if len(union) != len(table) {
+			t.Errorf("expected shards to hold %d keys, got %d", len(table), len(union))
+		}
+		for key, value := range table {
+			if union[key] != value {
+				t.Errorf("expected key %s to have value %d, got %d", key, value, union[key])
+			}
+		}
+		// 21 values split into 8, 7, and 6
+		for _, size := range sizes {
+			if size < 6 || size > 8 {
+				t.Errorf("expected balanced shard sizes, got %v", sizes)
+				break
+			}
+		}
+
+		// shards are independent of the trie
+		shards[0].Put("z", 99)
+		shards[0].Walk(func(key string, value int) error {
+			shards[0].Delete(key)
+			return nil
+		})
+		if value, ok := trie.Get("a/0"); !ok || value != table["a/0"] {
+			t.Errorf("expected key a/0 to have value %d, got %d", table["a/0"], value)
+		}
+		if _, ok := trie.Get("z"); ok {
+			t.Error("expected key z to be missing from the trie")
+		}
+
+		if shards := trie.Shard(0); shards != nil {
+			t.Errorf("expected nil shards, got %v", shards)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package trie
+
+// Snapshot is an opaque token capturing the key/values stored in a trie,
+// returned by Snapshot and passed to Restore, such as to undo changes.
+// Values are copied as by assignment, so values which reference memory,
+// such as pointers or slices, share it with the trie. Snapshots are
+// immutable and may be restored any number of times, to any trie.
+type Snapshot[T any] struct {
+	entries []KeyValue[T]
+}
+
+// Len returns the number of key/values captured by the snapshot.
+func (s *Snapshot[T]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.entries)
+}
+
+// Snapshot returns a Snapshot of the key/values stored in the trie, which
+// Restore returns the trie to. Snapshots copy every key/value rather than
+// sharing nodes with the trie, so taking one costs time and memory
+// proportional to the size of the trie.
+func (trie *runeTrie[T]) Snapshot() *Snapshot[T] {
+	return snapshot[T](trie)
+}
+
+// Restore returns the trie to the key/values captured by the snapshot,
+// deleting keys not in the snapshot and putting each key/value in it, in
+// time proportional to the sizes of the trie and the snapshot. A nil
+// snapshot is empty.
+func (trie *runeTrie[T]) Restore(s *Snapshot[T]) {
+	restore[T](trie, s)
+}
+
+// Snapshot returns a Snapshot of the key/values stored in the trie, which
+// Restore returns the trie to. Snapshots copy every key/value rather than
+// sharing nodes with the trie, so taking one costs time and memory
+// proportional to the size of the trie. Tombstones are not captured.
+func (trie *pathTrie[T]) Snapshot() *Snapshot[T] {
+	return snapshot[T](trie)
+}
+
+// Restore returns the trie to the key/values captured by the snapshot,
+// deleting keys not in the snapshot and putting each key/value in it, in
+// time proportional to the sizes of the trie and the snapshot. A nil
+// snapshot is empty. Restored keys are Put, so options such as
+// WithVersions or WithMaxEntries see them as changes.
+func (trie *pathTrie[T]) Restore(s *Snapshot[T]) {
+	restore[T](trie, s)
+}
+
+func snapshot[T any](trie Trie[T]) *Snapshot[T] {
+	s := &Snapshot[T]{}
+	trie.Walk(func(key string, value T) error {
+		s.entries = append(s.entries, KeyValue[T]{Key: key, Value: value})
+		return nil
+	})
+	return s
+}
+
+func restore[T any](trie CommonTrie[T], s *Snapshot[T]) {
+	var keys []string
+	trie.Walk(func(key string, value T) error {
+		keys = append(keys, key)
+		return nil
+	})
+	trie.DeleteAll(keys)
+	if s == nil {
+		return
+	}
+	for _, entry := range s.entries {
+		trie.Put(entry.Key, entry.Value)
+	}
+}
@@ -0,0 +1,73 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	for _, trie := range []CommonTrie[int]{NewRuneTrie[int](), NewPathTrie[int]()} {
+		before := map[string]int{
+			"":         0,
+			"/cat":     1,
+			"/cat/tom": 2,
+			"/dog":     3,
+		}
+		for key, value := range before {
+			trie.Put(key, value)
+		}
+		snap := trie.Snapshot()
+		if snap.Len() != len(before) {
+			t.Errorf("expected snapshot of %d key/values, got %d", len(before), snap.Len())
+		}
+
+		// mutate: update, delete, and add keys
+		trie.Put("/cat", 10)
+		trie.Delete("/dog")
+		trie.Put("/dog/rex", 4)
+		trie.Put("/bird", 5)
+		trie.Restore(snap)
+		if got := trieEntries(trie); !reflect.DeepEqual(got, before) {
+			t.Errorf("expected restored trie %v, got %v", before, got)
+		}
+
+		// snapshots may be restored again
+		trie.DeleteAll([]string{"", "/cat"})
+		trie.Restore(snap)
+		if got := trieEntries(trie); !reflect.DeepEqual(got, before) {
+			t.Errorf("expected restored trie %v, got %v", before, got)
+		}
+
+		// restoring an empty snapshot empties the trie
+		trie.Restore(nil)
+		if !trie.IsEmpty() {
+			t.Errorf("expected empty trie after restoring nil snapshot, got %v", trieEntries(trie))
+		}
+	}
+}
+
+func TestSnapshotRestoreOtherTrie(t *testing.T) {
+	source := NewPathTrie[int]()
+	source.Put("/a/b", 1)
+	source.Put("/c", 2)
+	snap := source.Snapshot()
+	source.Delete("/c")
+
+	target := NewRuneTrie[int]()
+	target.Put("/x", 3)
+	target.Restore(snap)
+	want := map[string]int{"/a/b": 1, "/c": 2}
+	if got := trieEntries[int](target); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected restored trie %v, got %v", want, got)
+	}
+}
+
+// trieEntries returns the key/values stored in the trie.
+func trieEntries[T any](trie Trie[T]) map[string]T {
+	entries := make(map[string]T)
+	trie.Walk(func(key string, value T) error {
+		entries[key] = value
+		return nil
+	})
+	return entries
+}
@@ -0,0 +1,81 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnsortedKey is returned when a SortedBuilder is given a key which sorts
+// before the previous key.
+var ErrUnsortedKey = errors.New("trie: key out of sorted order")
+
+// SortedBuilder builds a path trie from keys added in sorted order. It keeps
+// the nodes along the previous key, so each key only descends from where it
+// diverges from the previous key rather than from the root, which is faster
+// than a Put per key for bulk loads of sorted keys.
+type SortedBuilder[T any] struct {
+	trie    *pathTrie[T]
+	lastKey string
+	started bool
+	// parts of the previous key and the nodes along it, from the root
+	parts []string
+	nodes []*pathTrie[T]
+}
+
+// NewSortedBuilder allocates and returns a new SortedBuilder. Options
+// configure the path trie being built.
+func NewSortedBuilder[T any](opts ...PathTrieOption[T]) *SortedBuilder[T] {
+	trie := NewPathTrie(opts...).(*pathTrie[T])
+	return &SortedBuilder[T]{
+		trie:  trie,
+		nodes: []*pathTrie[T]{trie},
+	}
+}
+
+// Add inserts the value into the trie at the given key, replacing any value
+// added at the same key. Keys must be added in non-decreasing order, after
+// normalization if the trie has a key normalizer, or Add returns
+// ErrUnsortedKey. If the trie has a validator, values which fail validation
+// are not inserted and Add returns the validation error.
+func (b *SortedBuilder[T]) Add(key string, value T) error {
+	key = b.trie.normalizeKey(key)
+	if b.started && key < b.lastKey {
+		return fmt.Errorf("%w: %q after %q", ErrUnsortedKey, key, b.lastKey)
+	}
+	if err := b.trie.validate(key, value); err != nil {
+		return err
+	}
+	b.started = true
+	b.lastKey = key
+
+	segmenter := b.trie.config.segmenter
+	depth := 0 // depth of the node along the previous key
+	diverged := false
+	for part, i := segmenter(key, 0); part != ""; part, i = segmenter(key, i) {
+		if !diverged && depth < len(b.parts) && b.parts[depth] == part {
+			depth++
+			continue
+		}
+		if !diverged {
+			// drop the nodes of the previous key after the shared prefix
+			b.parts = b.parts[:depth]
+			b.nodes = b.nodes[:depth+1]
+			diverged = true
+		}
+		b.parts = append(b.parts, part)
+		b.nodes = append(b.nodes, b.nodes[depth].putChild(part))
+		depth++
+	}
+	if !diverged {
+		b.parts = b.parts[:depth]
+		b.nodes = b.nodes[:depth+1]
+	}
+	b.trie.putNode(key, b.parts, b.nodes[depth], &value)
+	return nil
+}
+
+// Build returns the trie holding the added key/values. The builder must not
+// be used after Build.
+func (b *SortedBuilder[T]) Build() PathTrie[T] {
+	return b.trie
+}
@@ -0,0 +1,60 @@
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSortedBuilder(t *testing.T) {
+	keys := []string{"", "/a", "/a/b", "/a/b/c", "/a/d", "/a/d", "/ab", "/b/c/d", "/b/e"}
+	builder := NewSortedBuilder[int]()
+	expected := NewPathTrie[int]()
+	for i, key := range keys {
+		if err := builder.Add(key, i); err != nil {
+			t.Errorf("expected error nil adding key %s, got %v", key, err)
+		}
+		expected.Put(key, i)
+	}
+	trie := builder.Build()
+	if !EqualComparable(trie, expected) {
+		t.Error("expected built trie to hold the added key/values")
+	}
+	if value, ok := trie.Get("/a/d"); !ok || value != 5 {
+		t.Errorf("expected key /a/d to have value 5, got %d", value)
+	}
+	if nodes, expectedNodes := nodeKeys(trie, ""), nodeKeys(expected, ""); !reflect.DeepEqual(nodes, expectedNodes) {
+		t.Errorf("expected nodes %v, got %v", expectedNodes, nodes)
+	}
+}
+
+func TestSortedBuilderUnsorted(t *testing.T) {
+	builder := NewSortedBuilder[int]()
+	builder.Add("/b", 1)
+	if err := builder.Add("/a", 2); !errors.Is(err, ErrUnsortedKey) {
+		t.Errorf("expected ErrUnsortedKey, got %v", err)
+	}
+	if _, ok := builder.Build().Get("/a"); ok {
+		t.Error("expected out of order key /a to not be added")
+	}
+}
+
+func TestSortedBuilderWithValidator(t *testing.T) {
+	errNegative := errors.New("negative value")
+	builder := NewSortedBuilder(WithValidator(func(key string, value int) error {
+		if value < 0 {
+			return errNegative
+		}
+		return nil
+	}))
+	if err := builder.Add("/a", -1); err != errNegative {
+		t.Errorf("expected validation error, got %v", err)
+	}
+	// a rejected key does not count towards the sorted order
+	if err := builder.Add("/", 1); err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if _, ok := builder.Build().Get("/a"); ok {
+		t.Error("expected invalid value to not be added")
+	}
+}
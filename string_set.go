@@ -0,0 +1,69 @@
+package trie
+
+import (
+	"errors"
+	"sort"
+)
+
+// StringSet is a set of string keys built on a Trie, for membership checks
+// such as blocklists.
+type StringSet struct {
+	trie CommonTrie[struct{}]
+}
+
+// NewStringSet allocates and returns a new StringSet which segments keys
+// path-wise. Options configure the underlying path trie.
+func NewStringSet(opts ...PathTrieOption[struct{}]) *StringSet {
+	return &StringSet{trie: NewPathTrie(opts...)}
+}
+
+// NewRuneStringSet allocates and returns a new StringSet which segments keys
+// rune-wise, so prefixes match any leading runes.
+func NewRuneStringSet() *StringSet {
+	return &StringSet{trie: NewRuneTrie[struct{}]()}
+}
+
+// Add adds the key to the set. It returns true if the key was not already a
+// member.
+func (s *StringSet) Add(key string) bool {
+	return s.trie.Put(key, struct{}{})
+}
+
+// Has returns true if the key is a member of the set.
+func (s *StringSet) Has(key string) bool {
+	_, ok := s.trie.Get(key)
+	return ok
+}
+
+// Remove removes the key from the set. It returns true if the key was a
+// member.
+func (s *StringSet) Remove(key string) bool {
+	if !s.Has(key) {
+		return false
+	}
+	return s.trie.Delete(key)
+}
+
+// errPrefixFound stops a WalkPath once a member is found.
+var errPrefixFound = errors.New("prefix found")
+
+// HasPrefix returns true if any member of the set is a prefix of the key,
+// including the key itself (e.g. a blocklist member "/admin" matches
+// "/admin/users").
+func (s *StringSet) HasPrefix(key string) bool {
+	err := s.trie.WalkPath(key, func(string, struct{}) error {
+		return errPrefixFound
+	})
+	return err == errPrefixFound
+}
+
+// KeysWithPrefix returns the sorted members of the set which have the given
+// prefix, including the prefix itself.
+func (s *StringSet) KeysWithPrefix(prefix string) []string {
+	var keys []string
+	for key := range s.trie.PrefixSeq(prefix) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringSet(t *testing.T) {
+	for _, set := range []*StringSet{NewStringSet(), NewRuneStringSet()} {
+		if added := set.Add("/admin"); !added {
+			t.Error("expected key /admin to be added")
+		}
+		if added := set.Add("/admin"); added {
+			t.Error("expected key /admin to be a member already")
+		}
+		set.Add("/admin/users")
+		set.Add("/internal")
+
+		for _, key := range []string{"/admin", "/admin/users", "/internal"} {
+			if !set.Has(key) {
+				t.Errorf("expected key %s to be a member", key)
+			}
+		}
+		for _, key := range []string{"", "/", "/admin/", "/public"} {
+			if set.Has(key) {
+				t.Errorf("expected key %s to not be a member", key)
+			}
+		}
+
+		if removed := set.Remove("/internal"); !removed {
+			t.Error("expected key /internal to be removed")
+		}
+		if removed := set.Remove("/internal"); removed {
+			t.Error("expected key /internal to not be a member")
+		}
+		if removed := set.Remove("/adm"); removed {
+			t.Error("expected key /adm to not be a member")
+		}
+		if set.Has("/internal") {
+			t.Error("expected key /internal to be removed")
+		}
+	}
+}
+
+func TestStringSetPrefixes(t *testing.T) {
+	set := NewStringSet()
+	for _, key := range []string{"/admin", "/admin/users", "/admin/users/new", "/api/v1"} {
+		set.Add(key)
+	}
+	for key, expected := range map[string]bool{
+		"/admin":           true,
+		"/admin/users/1":   true,
+		"/api/v1/pets":     true,
+		"/api":             false,
+		"/administrator":   false,
+		"/public/admin/x":  false,
+		"/api/v2/pets/cat": false,
+	} {
+		if has := set.HasPrefix(key); has != expected {
+			t.Errorf("expected HasPrefix(%s) to be %t, got %t", key, expected, has)
+		}
+	}
+	if keys := set.KeysWithPrefix("/admin"); !reflect.DeepEqual(keys, []string{"/admin", "/admin/users", "/admin/users/new"}) {
+		t.Errorf("expected members under /admin, got %v", keys)
+	}
+	if keys := set.KeysWithPrefix("/adm"); keys != nil {
+		t.Errorf("expected no members under path prefix /adm, got %v", keys)
+	}
+
+	// rune sets match any leading runes
+	set = NewRuneStringSet()
+	set.Add("bad")
+	set.Add("badger")
+	set.Add("bat")
+	if !set.HasPrefix("badly") {
+		t.Error("expected HasPrefix(badly) to be true")
+	}
+	if set.HasPrefix("ba") {
+		t.Error("expected HasPrefix(ba) to be false")
+	}
+	if keys := set.KeysWithPrefix("ba"); !reflect.DeepEqual(keys, []string{"bad", "badger", "bat"}) {
+		t.Errorf("expected members under ba, got %v", keys)
+	}
+}
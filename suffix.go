@@ -0,0 +1,91 @@
+package trie
+
+import (
+	"sort"
+	"strings"
+)
+
+// WithSuffixIndex maintains an index of the reversed keys of the values in
+// the path trie, so KeysWithSuffix finds the keys with a suffix in time
+// proportional to the number of matches rather than the size of the trie.
+// The index costs a rune trie node per rune of each reversed key plus a map
+// entry per value.
+func WithSuffixIndex[T any]() PathTrieOption[T] {
+	return func(trie *pathTrie[T]) {
+		trie.config.suffixes = NewRuneTrie[string]()
+		trie.config.suffixKeys = map[*pathTrie[T]]string{}
+	}
+}
+
+// indexSuffix indexes the key of the value node reached by the given
+// segments, which is the key Walk reports, replacing the key the node was
+// indexed under, if different.
+func (trie *pathTrie[T]) indexSuffix(parts []string, node *pathTrie[T]) {
+	if trie.config.suffixes == nil {
+		return
+	}
+	key := strings.Join(parts, "")
+	if old, ok := trie.config.suffixKeys[node]; ok {
+		if old == key {
+			return
+		}
+		trie.config.suffixes.Delete(reverseRunes(old))
+	}
+	trie.config.suffixKeys[node] = key
+	trie.config.suffixes.Put(reverseRunes(key), key)
+}
+
+// unindexSuffix removes the node's key from the suffix index, if indexed.
+func (trie *pathTrie[T]) unindexSuffix() {
+	if key, ok := trie.config.suffixKeys[trie]; ok {
+		trie.config.suffixes.Delete(reverseRunes(key))
+		delete(trie.config.suffixKeys, trie)
+	}
+}
+
+// KeysWithSuffix returns the sorted keys stored in the trie which end with
+// the given suffix, as Walk reports the keys. The suffix is normalized like
+// keys. With WithSuffixIndex the keys are found from the index, otherwise
+// every key is walked and filtered, taking time proportional to the size of
+// the trie.
+func (trie *pathTrie[T]) KeysWithSuffix(suffix string) []string {
+	suffix = trie.normalizeKey(suffix)
+	if trie.config.suffixes == nil {
+		return keysWithSuffix[T](trie, suffix)
+	}
+	var keys []string
+	for _, key := range trie.config.suffixes.PrefixSeq(reverseRunes(suffix)) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// KeysWithSuffix returns the sorted keys stored in the trie which end with
+// the given suffix. Rune tries are not indexed by suffix, so every key is
+// walked and filtered, taking time proportional to the size of the trie.
+// The suffix is normalized like keys.
+func (trie *runeTrie[T]) KeysWithSuffix(suffix string) []string {
+	return keysWithSuffix[T](trie, trie.normalizeKey(suffix))
+}
+
+func keysWithSuffix[T any](trie Trie[T], suffix string) []string {
+	var keys []string
+	trie.Walk(func(key string, value T) error {
+		if strings.HasSuffix(key, suffix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// reverseRunes returns s with its runes in reverse order.
+func reverseRunes(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,120 @@
+package trie
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeysWithSuffix(t *testing.T) {
+	tries := map[string]CommonTrie[int]{
+		"rune":         NewRuneTrie[int](),
+		"path":         NewPathTrie[int](),
+		"path indexed": NewPathTrie(WithSuffixIndex[int]()),
+	}
+	for name, trie := range tries {
+		for i, key := range []string{"", "/a.go", "/b/a.go", "/b/c.md", "/b/這.go", "/go"} {
+			trie.Put(key, i)
+		}
+		cases := map[string][]string{
+			"":       {"", "/a.go", "/b/a.go", "/b/c.md", "/b/這.go", "/go"},
+			".go":    {"/a.go", "/b/a.go", "/b/這.go"},
+			"go":     {"/a.go", "/b/a.go", "/b/這.go", "/go"},
+			"/a.go":  {"/a.go", "/b/a.go"},
+			"這.go":   {"/b/這.go"},
+			".txt":   nil,
+			"/b/c.m": nil,
+		}
+		for suffix, expected := range cases {
+			if keys := trie.KeysWithSuffix(suffix); !reflect.DeepEqual(keys, expected) {
+				t.Errorf("%s: expected keys with suffix %q %v, got %v", name, suffix, expected, keys)
+			}
+		}
+
+		// deleted and replaced keys are kept up to date
+		trie.Delete("/b/a.go")
+		trie.Put("/a.go", 10)
+		trie.Put("/c.go", 11)
+		if keys, expected := trie.KeysWithSuffix(".go"), []string{"/a.go", "/b/這.go", "/c.go"}; !reflect.DeepEqual(keys, expected) {
+			t.Errorf("%s: expected keys %v, got %v", name, expected, keys)
+		}
+	}
+}
+
+func TestPathTrieWithSuffixIndex(t *testing.T) {
+	// colliding keys are indexed by the latest key put
+	trie := NewPathTrie(WithSegmenter[int](foldingSegmenter), WithSuffixIndex[int]())
+	trie.Put("/Docs/README", 1)
+	trie.Put("/docs/readme", 2)
+	if keys := trie.KeysWithSuffix("README"); keys != nil {
+		t.Errorf("expected replaced key to be unindexed, got %v", keys)
+	}
+	if keys := trie.KeysWithSuffix("readme"); !reflect.DeepEqual(keys, []string{"/docs/readme"}) {
+		t.Errorf("expected keys [/docs/readme], got %v", keys)
+	}
+
+	// emptied tries leave an empty index
+	trie.Delete("/docs/readme")
+	config := trie.(*pathTrie[int]).config
+	if len(config.suffixKeys) != 0 || !config.suffixes.IsEmpty() {
+		t.Errorf("expected empty suffix index, got %v", config.suffixKeys)
+	}
+}
+
+func TestPathTrieKeysWithSuffixNormalized(t *testing.T) {
+	unescape := func(segment string) string {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			return unescaped
+		}
+		return segment
+	}
+	opts := []PathTrieOption[int]{WithKeyNormalizer[int](strings.ToLower), WithSegmentTransform[int](unescape)}
+	tries := map[string]CommonTrie[int]{
+		"path":         NewPathTrie(opts...),
+		"path indexed": NewPathTrie(append(opts, WithSuffixIndex[int]())...),
+	}
+	for name, trie := range tries {
+		trie.Put("/X%20Y", 1)
+		trie.Put("/a/B", 2)
+		cases := map[string][]string{
+			" y":   {"/x y"},
+			"x y":  {"/x y"},
+			"y":    {"/x y"},
+			"%20y": nil,
+			"B":    {"/a/b"},
+			"/A/B": {"/a/b"},
+		}
+		for suffix, expected := range cases {
+			if keys := trie.KeysWithSuffix(suffix); !reflect.DeepEqual(keys, expected) {
+				t.Errorf("%s: expected keys with suffix %q %v, got %v", name, suffix, expected, keys)
+			}
+		}
+	}
+}
+
+func TestPathTrieWithSuffixIndexPutPath(t *testing.T) {
+	trie := NewPathTrie(WithSuffixIndex[int]())
+	trie.PutPath([]string{"/a", "/b"}, 1)
+	trie.Put("/c/b", 2)
+	if keys := trie.KeysWithSuffix("/b"); !reflect.DeepEqual(keys, []string{"/a/b", "/c/b"}) {
+		t.Errorf("expected keys [/a/b /c/b], got %v", keys)
+	}
+	trie.DeletePath([]string{"/a", "/b"})
+	if keys := trie.KeysWithSuffix("/b"); !reflect.DeepEqual(keys, []string{"/c/b"}) {
+		t.Errorf("expected keys [/c/b], got %v", keys)
+	}
+}
+
+func TestSortedBuilderWithSuffixIndex(t *testing.T) {
+	builder := NewSortedBuilder(WithSuffixIndex[int]())
+	for i, key := range []string{"/a/x.go", "/a/y.md", "/b/z.go"} {
+		if err := builder.Add(key, i); err != nil {
+			t.Fatalf("expected error nil, got %v", err)
+		}
+	}
+	trie := builder.Build()
+	if keys := trie.KeysWithSuffix(".go"); !reflect.DeepEqual(keys, []string{"/a/x.go", "/b/z.go"}) {
+		t.Errorf("expected keys [/a/x.go /b/z.go], got %v", keys)
+	}
+}
@@ -0,0 +1,72 @@
+package trie
+
+import "encoding"
+
+// TextTrie is a trie with keys of type K, which are marshaled to the string
+// keys of a path trie with their MarshalText method. MarshalText must be
+// deterministic and marshal distinct keys to distinct text, such as paths
+// in which related keys share prefixes. Get, Put, and Delete treat keys
+// which fail to marshal as missing; the checked variants return the error.
+type TextTrie[K encoding.TextMarshaler, T any] struct {
+	trie PathTrie[T]
+}
+
+// NewTextTrie allocates and returns a new TextTrie. Options configure the
+// underlying path trie.
+func NewTextTrie[K encoding.TextMarshaler, T any](opts ...PathTrieOption[T]) *TextTrie[K, T] {
+	return &TextTrie[K, T]{trie: NewPathTrie(opts...)}
+}
+
+// Get returns the value stored at the given key. Returns false if the key
+// fails to marshal.
+func (t *TextTrie[K, T]) Get(key K) (T, bool) {
+	value, ok, _ := t.GetChecked(key)
+	return value, ok
+}
+
+// GetChecked returns the value stored at the given key, or the error from
+// marshaling the key.
+func (t *TextTrie[K, T]) GetChecked(key K) (T, bool, error) {
+	text, err := key.MarshalText()
+	if err != nil {
+		return zeroValueOfT[T](), false, err
+	}
+	value, ok := t.trie.Get(string(text))
+	return value, ok, nil
+}
+
+// Put inserts the value into the trie at the given key, replacing any
+// existing value. It returns true if the put adds a new value, false if it
+// replaces an existing value or the key fails to marshal.
+func (t *TextTrie[K, T]) Put(key K, value T) bool {
+	isNew, _ := t.PutChecked(key, value)
+	return isNew
+}
+
+// PutChecked inserts the value into the trie at the given key, as Put does,
+// or returns the error from marshaling the key without inserting the value.
+func (t *TextTrie[K, T]) PutChecked(key K, value T) (bool, error) {
+	text, err := key.MarshalText()
+	if err != nil {
+		return false, err
+	}
+	return t.trie.Put(string(text), value), nil
+}
+
+// Delete removes the value associated with the given key. Returns true if a
+// node was found for the given key, false if not or if the key fails to
+// marshal.
+func (t *TextTrie[K, T]) Delete(key K) bool {
+	found, _ := t.DeleteChecked(key)
+	return found
+}
+
+// DeleteChecked removes the value associated with the given key, as Delete
+// does, or returns the error from marshaling the key.
+func (t *TextTrie[K, T]) DeleteChecked(key K) (bool, error) {
+	text, err := key.MarshalText()
+	if err != nil {
+		return false, err
+	}
+	return t.trie.Delete(string(text)), nil
+}
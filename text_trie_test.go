@@ -0,0 +1,90 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// regionKey marshals to a path of its region, zone, and host.
+type regionKey struct {
+	region string
+	zone   string
+	host   string
+}
+
+var errEmptyRegion = errors.New("empty region")
+
+func (k regionKey) MarshalText() ([]byte, error) {
+	if k.region == "" {
+		return nil, errEmptyRegion
+	}
+	return fmt.Appendf(nil, "/%s/%s/%s", k.region, k.zone, k.host), nil
+}
+
+func TestTextTrie(t *testing.T) {
+	trie := NewTextTrie[regionKey, int]()
+	web := regionKey{region: "us-east", zone: "a", host: "web1"}
+	if isNew := trie.Put(web, 1); !isNew {
+		t.Error("expected key web1 to be missing")
+	}
+	trie.Put(regionKey{region: "us-east", zone: "b", host: "db1"}, 2)
+	if value, ok := trie.Get(regionKey{region: "us-east", zone: "a", host: "web1"}); !ok || value != 1 {
+		t.Errorf("expected key web1 to have value 1, got (%d, %t)", value, ok)
+	}
+	if value, ok := trie.Get(regionKey{region: "us-west", zone: "a", host: "web1"}); ok {
+		t.Errorf("expected key web1 in us-west to be missing, got %d", value)
+	}
+	if isNew := trie.Put(web, 3); isNew {
+		t.Error("expected key web1 to be replaced")
+	}
+
+	// keys marshal to hierarchical paths
+	if keys := trie.trie.ChildrenKeys("/us-east"); len(keys) != 2 {
+		t.Errorf("expected 2 zones under /us-east, got %v", keys)
+	}
+	if value, _ := trie.trie.Get("/us-east/a/web1"); value != 3 {
+		t.Errorf("expected marshaled key /us-east/a/web1 to have value 3, got %d", value)
+	}
+
+	if !trie.Delete(web) {
+		t.Error("expected key web1 to be deleted")
+	}
+	if _, ok := trie.Get(web); ok {
+		t.Error("expected key web1 to be missing after delete")
+	}
+}
+
+func TestTextTrieMarshalError(t *testing.T) {
+	trie := NewTextTrie[regionKey, int]()
+	bad := regionKey{zone: "a", host: "web1"}
+	if trie.Put(bad, 1) {
+		t.Error("expected Put of a key which fails to marshal to return false")
+	}
+	if _, err := trie.PutChecked(bad, 1); !errors.Is(err, errEmptyRegion) {
+		t.Errorf("expected PutChecked error %v, got %v", errEmptyRegion, err)
+	}
+	if !trie.trie.IsEmpty() {
+		t.Error("expected key which fails to marshal to not be put")
+	}
+	if _, ok, err := trie.GetChecked(bad); ok || !errors.Is(err, errEmptyRegion) {
+		t.Errorf("expected GetChecked error %v, got (%t, %v)", errEmptyRegion, ok, err)
+	}
+	if _, ok := trie.Get(bad); ok {
+		t.Error("expected Get of a key which fails to marshal to miss")
+	}
+	if found, err := trie.DeleteChecked(bad); found || !errors.Is(err, errEmptyRegion) {
+		t.Errorf("expected DeleteChecked error %v, got (%t, %v)", errEmptyRegion, found, err)
+	}
+
+	good := regionKey{region: "eu", zone: "a", host: "web1"}
+	if isNew, err := trie.PutChecked(good, 1); !isNew || err != nil {
+		t.Errorf("expected PutChecked to add key, got (%t, %v)", isNew, err)
+	}
+	if value, ok, err := trie.GetChecked(good); value != 1 || !ok || err != nil {
+		t.Errorf("expected GetChecked to return (1, true, nil), got (%d, %t, %v)", value, ok, err)
+	}
+	if found, err := trie.DeleteChecked(good); !found || err != nil {
+		t.Errorf("expected DeleteChecked to delete key, got (%t, %v)", found, err)
+	}
+}
@@ -0,0 +1,104 @@
+package trie
+
+// Entry is the state of a key in a path trie.
+type Entry int
+
+const (
+	// EntryAbsent means the key has no value or tombstone.
+	EntryAbsent Entry = iota
+	// EntryValue means the key has a value.
+	EntryValue
+	// EntryTombstone means the key is marked as known to be absent.
+	EntryTombstone
+)
+
+// String returns the name of the Entry state.
+func (e Entry) String() string {
+	switch e {
+	case EntryValue:
+		return "Value"
+	case EntryTombstone:
+		return "Tombstone"
+	default:
+		return "Absent"
+	}
+}
+
+// PutTombstone marks the given key as known to be absent, replacing any
+// existing value. Get reports a tombstoned key as missing without calling
+// the loader, while GetEntry reports it as EntryTombstone. Tombstones are
+// removed by Delete or replaced by Put.
+func (trie *pathTrie[T]) PutTombstone(key string) {
+	key = trie.normalizeKey(key)
+	node := trie
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		node = node.putChild(part)
+	}
+	node.clearValue()
+	if trie.config.tombstones == nil {
+		trie.config.tombstones = map[*pathTrie[T]]struct{}{}
+	}
+	trie.config.tombstones[node] = struct{}{}
+}
+
+// GetEntry returns the value stored at the given key and the state of the
+// key, distinguishing tombstoned keys from absent keys. It does not call the
+// loader.
+func (trie *pathTrie[T]) GetEntry(key string) (T, Entry) {
+	key = trie.normalizeKey(key)
+	node := trie
+	for part, i := trie.config.segmenter(key, 0); part != ""; part, i = trie.config.segmenter(key, i) {
+		node = node.child(part)
+		if node == nil {
+			return zeroValueOfT[T](), EntryAbsent
+		}
+	}
+	if node.value != nil {
+		return trie.readValue(*node.value), EntryValue
+	}
+	if node.isTombstone() {
+		return zeroValueOfT[T](), EntryTombstone
+	}
+	return zeroValueOfT[T](), EntryAbsent
+}
+
+// WalkTombstones iterates over each tombstoned key in the trie and calls the
+// given walker function with the key. If the walker function returns an
+// error, the walk is aborted.
+// The traversal is depth first with no guaranteed order.
+func (trie *pathTrie[T]) WalkTombstones(walker func(key string) error) error {
+	if len(trie.config.tombstones) == 0 {
+		return nil
+	}
+	return trie.walkTombstones("", walker)
+}
+
+func (trie *pathTrie[T]) walkTombstones(key string, walker func(key string) error) error {
+	if trie.isTombstone() {
+		if err := walker(key); err != nil {
+			return err
+		}
+	}
+	for part, child := range trie.childNodes() {
+		if err := child.walkTombstones(key+part, walker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTombstone returns true if the node is marked by PutTombstone.
+func (trie *pathTrie[T]) isTombstone() bool {
+	if trie.config.tombstones == nil {
+		return false
+	}
+	_, ok := trie.config.tombstones[trie]
+	return ok
+}
+
+// clearTombstone removes the node's tombstone, if any.
+func (trie *pathTrie[T]) clearTombstone() {
+	if trie.config.tombstones != nil {
+		delete(trie.config.tombstones, trie)
+	}
+}
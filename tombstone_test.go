@@ -0,0 +1,95 @@
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPathTrieTombstones(t *testing.T) {
+	var loads int
+	trie := NewPathTrie(WithLoader(func(key string) (int, bool) {
+		loads++
+		return 0, false
+	}))
+	trie.Put("/cat", 1)
+	trie.Put("/cat/gideon", 2)
+	trie.PutTombstone("/cat/gideon")
+	trie.PutTombstone("/dog/rex")
+
+	cases := []struct {
+		key   string
+		value int
+		entry Entry
+	}{
+		{"/cat", 1, EntryValue},
+		{"/cat/gideon", 0, EntryTombstone},
+		{"/dog/rex", 0, EntryTombstone},
+		{"/dog", 0, EntryAbsent},
+		{"/fish", 0, EntryAbsent},
+	}
+	for _, c := range cases {
+		if value, entry := trie.GetEntry(c.key); value != c.value || entry != c.entry {
+			t.Errorf("expected key %s to have %v %d, got %v %d", c.key, c.entry, c.value, entry, value)
+		}
+	}
+
+	// Get reports tombstones as missing without loading them
+	for _, key := range []string{"/cat/gideon", "/dog/rex"} {
+		if value, ok := trie.Get(key); ok {
+			t.Errorf("expected tombstoned key %s to be missing, found value %v", key, value)
+		}
+	}
+	if loads != 0 {
+		t.Errorf("expected tombstoned keys to not be loaded, got %d loads", loads)
+	}
+
+	// tombstones are walkable, but not walked as values
+	var tombstones []string
+	trie.WalkTombstones(func(key string) error {
+		tombstones = append(tombstones, key)
+		return nil
+	})
+	sort.Strings(tombstones)
+	if expected := []string{"/cat/gideon", "/dog/rex"}; !reflect.DeepEqual(tombstones, expected) {
+		t.Errorf("expected tombstones %v, got %v", expected, tombstones)
+	}
+	trie.Walk(func(key string, value int) error {
+		if key != "/cat" {
+			t.Errorf("expected only key /cat to be walked, got %s", key)
+		}
+		return nil
+	})
+	walkerError := errors.New("walker error")
+	if err := trie.WalkTombstones(func(key string) error { return walkerError }); err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+
+	// deleting a value does not clean up a tombstoned ancestor
+	trie.Put("/dog/rex/toy", 3)
+	trie.Delete("/dog/rex/toy")
+	if _, entry := trie.GetEntry("/dog/rex"); entry != EntryTombstone {
+		t.Errorf("expected key /dog/rex to remain a tombstone, got %v", entry)
+	}
+
+	// tombstones are deletable and replaced by puts
+	if deleted := trie.Delete("/dog/rex"); !deleted {
+		t.Error("expected tombstone /dog/rex to be deleted")
+	}
+	if _, entry := trie.GetEntry("/dog/rex"); entry != EntryAbsent {
+		t.Errorf("expected key /dog/rex to be absent, got %v", entry)
+	}
+	if isNew := trie.Put("/cat/gideon", 4); !isNew {
+		t.Error("expected put over a tombstone to add a new value")
+	}
+	if value, entry := trie.GetEntry("/cat/gideon"); value != 4 || entry != EntryValue {
+		t.Errorf("expected key /cat/gideon to have value 4, got %v %d", entry, value)
+	}
+	if tracked := len(trie.(*pathTrie[int]).config.tombstones); tracked != 0 {
+		t.Errorf("expected no tracked tombstones, got %d", tracked)
+	}
+	if trie.(*pathTrie[int]).child("/dog") != nil {
+		t.Error("expected nodes /dog and /dog/rex to be cleaned up")
+	}
+}
@@ -1,5 +1,12 @@
 package trie
 
+import (
+	"io"
+	"iter"
+	"math/rand/v2"
+	"time"
+)
+
 // Trie exposes the Trie structure capabilities.
 type Trie[T any] interface {
 	Get(key string) (T, bool)
@@ -8,3 +15,92 @@ type Trie[T any] interface {
 	Walk(walker WalkFunc[T]) error
 	WalkPath(key string, walker WalkFunc[T]) error
 }
+
+// CommonTrie exposes the capabilities shared by path-wise and rune-wise
+// Tries beyond those of Trie. Trie is kept small so other types may
+// implement it, while capabilities are added to CommonTrie, PathTrie, and
+// RuneTrie.
+type CommonTrie[T any] interface {
+	Trie[T]
+	WalkPathRemainder(key string, walker func(matchedKey string, value T, remainder string) error) error
+	CommonPrefix() string
+	PrefixSeq(prefix string) iter.Seq2[string, T]
+	LoadLines(r io.Reader, parse func(line string) (key string, value T, ok bool)) (int, error)
+	WalkRange(lo, hi string, walker WalkFunc[T]) error
+	WalkMutate(walker func(key string, value *T) error) error
+	DeepestKey() (string, int)
+	ReplaceSubtree(prefix string, replacement Trie[T]) int
+	DeletePrefix(prefix string) int
+	DryRunDeletePrefix(prefix string) (count int, keys []string)
+	TopLevelCount() int
+	Touch(key string)
+	Find(pred func(key string, value T) bool) (string, T, bool)
+	WalkPostOrder(walker WalkFunc[T]) error
+	Parent(key string) (parentKey string, value T, ok bool)
+	ChildrenKeys(key string) []string
+	WalkGrouped(walker GroupedWalkFunc[T]) error
+	PutRef(key string, value *T) bool
+	WalkWithMetrics(walker WalkFunc[T]) (WalkMetrics, error)
+	IsEmpty() bool
+	WalkLeaves(walker WalkFunc[T]) error
+	WalkBetweenPrefixes(fromPrefix, toPrefix string, walker WalkFunc[T]) error
+	Shard(n int) []CommonTrie[T]
+	WalkDelete(walker func(key string, value T) (delete bool, err error)) error
+	GetWithDepth(key string) (value T, depth int, ok bool)
+	WalkDeadline(deadline time.Time, walker WalkFunc[T]) error
+	NumInternalNodes() int
+	WalkLimit(limit int, walker WalkFunc[T]) error
+	WalkCollectErrors(walker WalkFunc[T]) []error
+	Ancestors(key string) []KeyValue[T]
+	Explain(key string) string
+	DeleteAll(keys []string) int
+	BranchingHistogram() map[int]int
+	WalkTree(walker TreeWalkFunc[T]) error
+	Sample(rng *rand.Rand) (key string, value T, ok bool)
+	KeysToDepth(depth int) []string
+	MinKey() (key string, ok bool)
+	MaxKey() (key string, ok bool)
+	NearestByPrefix(key string) (string, T, bool)
+	WalkWithChildCount(walker func(key string, value T, childCount int) error) error
+	Rekey(transform func(oldKey string) string) CommonTrie[T]
+	EncodeJSONStream(w io.Writer) error
+	Snapshot() *Snapshot[T]
+	Restore(s *Snapshot[T])
+	KeysWithSuffix(suffix string) []string
+}
+
+// RuneTrie exposes the capabilities specific to rune-wise Tries.
+type RuneTrie[T any] interface {
+	CommonTrie[T]
+	FuzzySearch(query string, maxDist int) []string
+}
+
+// PathTrie exposes the capabilities specific to path-wise Tries.
+type PathTrie[T any] interface {
+	CommonTrie[T]
+	WalkSegments(walker SegmentsWalkFunc[T]) error
+	PutChecked(key string, value T) error
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary(data []byte) error
+	GetPath(segments []string) (T, bool)
+	PutPath(segments []string, value T) bool
+	DeletePath(segments []string) bool
+	WalkInsertionOrder(walker WalkFunc[T]) error
+	PutTombstone(key string)
+	GetEntry(key string) (T, Entry)
+	WalkTombstones(walker func(key string) error) error
+	Prune() int
+	ToNestedMap() map[string]any
+	Version() uint64
+	ChangedSince(version uint64) []string
+	WalkPathReport(key string) (matchedSegments []string, unmatched string)
+	LongestPrefixSegments(key string) (segments []string, value T, ok bool)
+	TopKeys(n int) []string
+	WalkGlob(pattern string, walker WalkFunc[T]) error
+	DeleteGlob(pattern string) int
+	WalkFiltered(keep func(segment string, depth int) bool, walker WalkFunc[T]) error
+	PutReportingCollision(key string, value T) (collidedWith string, collided bool)
+	WalkDirty(walker WalkFunc[T]) error
+	ClearDirty()
+	WastedNodes() int
+}
@@ -2,7 +2,19 @@ package trie
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // rune trie
@@ -46,16 +58,439 @@ func TestRuneTrieWalkError(t *testing.T) {
 	testTrieWalkError(t, trie)
 }
 
+func TestRuneTrieWalkReentrant(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTrieWalkReentrant(t, trie)
+}
+
+func TestRuneTrieWalkPostOrder(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTrieWalkPostOrder(t, trie)
+}
+
+func TestRuneTrieParent(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTrieParent(t, trie)
+}
+
+func TestRuneTrieWalkGrouped(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	for _, key := range []string{"ab", "ac", "a", "b", "bcd", "這是"} {
+		trie.Put(key, key)
+	}
+	expected := []groupedWalk{
+		{"", []KeyValue[any]{{"a", "a"}, {"b", "b"}}},
+		{"a", []KeyValue[any]{{"ab", "ab"}, {"ac", "ac"}}},
+		{"bc", []KeyValue[any]{{"bcd", "bcd"}}},
+		{"這", []KeyValue[any]{{"這是", "這是"}}},
+	}
+	testTrieWalkGrouped(t, trie, expected)
+}
+
+func TestRuneTriePutRef(t *testing.T) {
+	testTriePutRef(t, NewRuneTrie[[]int]())
+}
+
+func TestRuneTrieWalkWithMetrics(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	for _, key := range []string{"ab", "abcd", "b", "這是"} {
+		trie.Put(key, key)
+	}
+	// root, a, ab, abc, abcd, b, 這, 這是
+	expected := WalkMetrics{NodesVisited: 8, ValuesDelivered: 4, MaxDepth: 4}
+	testTrieWalkWithMetrics(t, trie, expected)
+}
+
+func TestRuneTrieIsEmpty(t *testing.T) {
+	testTrieIsEmpty(t, NewRuneTrie[any]())
+}
+
+func TestRuneTrieWalkLeaves(t *testing.T) {
+	testTrieWalkLeaves(t, NewRuneTrie[any]())
+}
+
+func TestRuneTrieWalkBetweenPrefixes(t *testing.T) {
+	testTrieWalkBetweenPrefixes(t, NewRuneTrie[any](), nil)
+}
+
+func TestRuneTrieNil(t *testing.T) {
+	var trie *runeTrie[any]
+	testTrieNil(t, trie)
+}
+
+func TestRuneTrieWalkDelete(t *testing.T) {
+	testTrieWalkDelete(t, NewRuneTrie[int]())
+}
+
+func TestRuneTrieGetWithDepth(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	for _, key := range []string{"", "/cat", "這是"} {
+		trie.Put(key, key)
+	}
+	cases := map[string]int{"": 0, "/cat": 4, "這是": 2}
+	for key, expected := range cases {
+		if value, depth, ok := trie.GetWithDepth(key); !ok || value != key || depth != expected {
+			t.Errorf("expected key %s to have value %s at depth %d, got (%v, %d, %t)", key, key, expected, value, depth, ok)
+		}
+	}
+	if value, depth, ok := trie.GetWithDepth("/ca"); ok || depth != 0 {
+		t.Errorf("expected key /ca to be missing, got (%v, %d, %t)", value, depth, ok)
+	}
+}
+
+func TestRuneTrieWalkDeadline(t *testing.T) {
+	testTrieWalkDeadline(t, NewRuneTrie[any]())
+}
+
+func TestRuneTrieNumInternalNodes(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	if count := trie.NumInternalNodes(); count != 0 {
+		t.Errorf("expected 0 internal nodes, got %d", count)
+	}
+	for _, key := range []string{"", "ab", "abcd", "b", "這是"} {
+		trie.Put(key, key)
+	}
+	// a, abc, 這
+	if count := trie.NumInternalNodes(); count != 3 {
+		t.Errorf("expected 3 internal nodes, got %d", count)
+	}
+}
+
+func TestRuneTrieBranchingHistogram(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	if histogram := trie.BranchingHistogram(); len(histogram) != 0 {
+		t.Errorf("expected empty histogram, got %v", histogram)
+	}
+	for _, key := range []string{"ab", "abcd", "ac", "b", "這是"} {
+		trie.Put(key, key)
+	}
+	// root: a, b, 這; a: b, c; ab: c; abc: d; 這: 是
+	expected := map[int]int{3: 1, 2: 1, 1: 3}
+	if histogram := trie.BranchingHistogram(); !reflect.DeepEqual(histogram, expected) {
+		t.Errorf("expected histogram %v, got %v", expected, histogram)
+	}
+}
+
+func TestRuneTrieWalkLimit(t *testing.T) {
+	testTrieWalkLimit(t, NewRuneTrie[any]())
+}
+
+func TestRuneTrieWalkCollectErrors(t *testing.T) {
+	testTrieWalkCollectErrors(t, NewRuneTrie[any]())
+}
+
+func TestRuneTrieAncestors(t *testing.T) {
+	testTrieAncestors(t, NewRuneTrie[any]())
+}
+
+func TestRuneTrieExplain(t *testing.T) {
+	testTrieExplain(t, NewRuneTrie[any](), map[string]string{
+		"/a/b":   `found a value at "/a/b"`,
+		"/a/c":   `matched up to "/a/", no child 'c'`,
+		"/a":     `matched "/a", but it holds no value`,
+		"x":      `no child 'x' of the root`,
+		"/a/b/c": `matched up to "/a/b", no child '/'`,
+	})
+}
+
+func TestRuneTrieDeleteAll(t *testing.T) {
+	testTrieDeleteAll(t, func() CommonTrie[int] { return NewRuneTrie[int]() })
+}
+
+func TestRuneTrieWalkTree(t *testing.T) {
+	testTrieWalkTree(t, NewRuneTrie[any](), []string{"ab", "ac", "b"}, []treeNode{
+		{"", nil, true, 0},
+		{"a", nil, false, 1},
+		{"ab", "ab", false, 2},
+		{"ac", "ac", true, 2},
+		{"b", "b", true, 1},
+	})
+}
+
+func TestRuneTrieSample(t *testing.T) {
+	testTrieSample(t, NewRuneTrie[any]())
+}
+
+func TestRuneTrieKeysToDepth(t *testing.T) {
+	testTrieKeysToDepth(t, NewRuneTrie[any](), []string{"", "a", "ab", "abc", "b", "這是"}, map[int][]string{
+		-1: nil,
+		0:  {""},
+		1:  {"", "a", "b"},
+		2:  {"", "a", "ab", "b", "這是"},
+		5:  {"", "a", "ab", "abc", "b", "這是"},
+	})
+}
+
+func TestRuneTrieMinMaxKey(t *testing.T) {
+	testTrieMinMaxKey(t, NewRuneTrie[any](), []string{"b", "ba", "a/z", "這"}, []string{"0", "📦"}, "a/z", "這")
+}
+
+func TestRuneTrieNearestByPrefix(t *testing.T) {
+	testTrieNearestByPrefix(t, NewRuneTrie[any](), []string{"apple", "apply", "apricot", "banana", "band"}, "apx", map[string]string{
+		"apple":    "apple",
+		"applx":    "apple",
+		"applyz":   "apply",
+		"apz":      "apple",
+		"apricots": "apricot",
+		"bandana":  "band",
+		"banz":     "banana",
+		"apxy":     "apple",
+		"c":        "apple",
+		"":         "apple",
+	})
+}
+
+func TestRuneTrieWalkWithChildCount(t *testing.T) {
+	testTrieWalkWithChildCount(t, NewRuneTrie[any](), []string{"", "a", "ab", "ac", "acd"}, map[string]int{
+		"":    1,
+		"a":   2,
+		"ab":  0,
+		"ac":  1,
+		"acd": 0,
+	})
+}
+
+func TestRuneTrieRekey(t *testing.T) {
+	testTrieRekey(t, NewRuneTrie[int]())
+}
+
 func TestRuneTrieWalkPath(t *testing.T) {
 	trie := NewRuneTrie[any]()
 	testTrieWalkPath(t, trie)
 }
 
+func TestRuneTrieWalkPathRemainder(t *testing.T) {
+	testTrieWalkPathRemainder(t, NewRuneTrie[any]())
+}
+
 func TestRuneTrieWalkPathError(t *testing.T) {
 	trie := NewRuneTrie[any]()
 	testTrieWalkPathError(t, trie)
 }
 
+func TestRuneTrieCommonPrefix(t *testing.T) {
+	testTrieCommonPrefix(t, func() CommonTrie[any] { return NewRuneTrie[any]() }, []commonPrefixCase{
+		{[]string{}, ""},
+		{[]string{"/notes"}, "/notes"},
+		{[]string{"/notes/new", "/notes/:id"}, "/notes/"},
+		{[]string{"/notes/new", "/notes/nose"}, "/notes/n"},
+		{[]string{"/cat", "/cat/gideon", "/cats"}, "/cat"},
+		{[]string{"這是第三個值", "這是第三"}, "這是第三"},
+		{[]string{"fish", "/cat"}, ""},
+		{[]string{"", "/cat"}, ""},
+	})
+}
+
+func TestRuneTriePrefixSeq(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTriePrefixSeq(t, trie)
+}
+
+func TestRuneTrieLoadLines(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTrieLoadLines(t, trie)
+}
+
+func TestRuneTrieWalkRange(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTrieWalkRange(t, trie, nil)
+
+	// rune tries order keys lexicographically
+	trie = NewRuneTrie[any]()
+	trie.Put("/a/b", 1)
+	trie.Put("/a-b", 2)
+	var keys []string
+	trie.WalkRange("/a", "/b", func(key string, value any) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if expected := []string{"/a-b", "/a/b"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+}
+
+func TestRuneTrieWalkMutate(t *testing.T) {
+	trie := NewRuneTrie[int]()
+	testTrieWalkMutate(t, trie)
+}
+
+func TestRuneTrieDeepestKey(t *testing.T) {
+	testTrieDeepestKey(t, func() CommonTrie[any] { return NewRuneTrie[any]() }, []deepestKeyCase{
+		{[]string{}, []string{""}, 0},
+		{[]string{""}, []string{""}, 0},
+		{[]string{"/cat", "/caterpillar", "/dog"}, []string{"/caterpillar"}, 12},
+		{[]string{"a", "ab", "abc", "b"}, []string{"abc"}, 3},
+		{[]string{"這是第三個值", "/cat"}, []string{"這是第三個值"}, 6},
+	})
+}
+
+func TestRuneTrieReplaceSubtree(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTrieReplaceSubtree(t, trie, NewRuneTrie[any]())
+}
+
+func TestRuneTrieDeletePrefix(t *testing.T) {
+	testTrieDeletePrefix(t, func() CommonTrie[any] { return NewRuneTrie[any]() }, map[string][]string{
+		"":          {"", "/cat", "/config", "/config/a", "/config/a/b", "/config/c"},
+		"/c":        {"/cat", "/config", "/config/a", "/config/a/b", "/config/c"},
+		"/config/a": {"/config/a", "/config/a/b"},
+		"/config/":  {"/config/a", "/config/a/b", "/config/c"},
+		"/dog":      nil,
+	})
+}
+
+func TestRuneTrieTopLevelCount(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	if count := trie.TopLevelCount(); count != 0 {
+		t.Errorf("expected empty trie to have 0 top level runes, got %d", count)
+	}
+	for _, key := range []string{"", "/cat", "/dog", "fish", "fig", "這是"} {
+		trie.Put(key, key)
+	}
+	if count := trie.TopLevelCount(); count != 3 {
+		t.Errorf("expected 3 top level runes, got %d", count)
+	}
+}
+
+func TestRuneTrieChildrenKeys(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	for _, key := range []string{"/cat", "/cow", "/co", "/b", "這是"} {
+		trie.Put(key, key)
+	}
+	cases := map[string][]string{
+		"":     {"/", "這"},
+		"/":    {"/b", "/c"},
+		"/c":   {"/ca", "/co"},
+		"這":    {"這是"},
+		"/cat": nil, // leaf
+		"/x":   nil, // missing
+	}
+	for key, expected := range cases {
+		if keys := trie.ChildrenKeys(key); !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected key %q to have children keys %v, got %v", key, expected, keys)
+		}
+	}
+}
+
+func TestRuneTrieTouch(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTrieTouch(t, trie)
+
+	runeTrie := trie.(*runeTrie[any])
+	node := runeTrie.children['/'].children['a'].children['/'].children['b']
+	if node == nil {
+		t.Fatal("expected Touch to create nodes along key /a/b")
+	}
+	trie.Put("/a/b", 1)
+	if runeTrie.children['/'].children['a'].children['/'].children['b'] != node {
+		t.Error("expected Put to reuse the nodes created by Touch")
+	}
+}
+
+func TestRuneTrieFind(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	testTrieFind(t, trie)
+}
+
+func TestRuneTrieWithUnicodeNormalization(t *testing.T) {
+	const composed = "caf\u00e9"    // "café" with a single code point é
+	const decomposed = "cafe\u0301" // "café" with e and a combining accent
+
+	// without normalization the forms are distinct keys
+	trie := NewRuneTrie[any]()
+	trie.Put(composed, 1)
+	if value, ok := trie.Get(decomposed); ok {
+		t.Errorf("expected decomposed key to be missing, found value %v", value)
+	}
+
+	trie = NewRuneTrie(WithUnicodeNormalization[any](norm.NFC))
+	if isNew := trie.Put(decomposed, 1); !isNew {
+		t.Error("expected decomposed key to be missing")
+	}
+	if isNew := trie.Put(composed, 2); isNew {
+		t.Error("expected composed key to normalize to an existing key")
+	}
+	for _, key := range []string{composed, decomposed} {
+		if value, ok := trie.Get(key); !ok || value != 2 {
+			t.Errorf("expected key %q to have value 2, got %v", key, value)
+		}
+	}
+
+	// walks return normalized keys
+	trie.Walk(func(key string, value any) error {
+		if key != composed {
+			t.Errorf("expected walked key %q, got %q", composed, key)
+		}
+		return nil
+	})
+
+	// paths are walked by whole runes
+	trie.Put("caf", 0)
+	var path []string
+	trie.WalkPath(decomposed+"s", func(key string, value any) error {
+		path = append(path, key)
+		return nil
+	})
+	if expected := []string{"caf", composed}; !reflect.DeepEqual(path, expected) {
+		t.Errorf("expected path keys %q, got %q", expected, path)
+	}
+	trie.Delete("caf")
+
+	// queries and bounds are normalized like keys
+	if keys := trie.FuzzySearch("cafe\u0301s", 1); !reflect.DeepEqual(keys, []string{composed}) {
+		t.Errorf("expected fuzzy search to find %q, got %q", composed, keys)
+	}
+	var walked []string
+	trie.WalkRange(decomposed, decomposed+"z", func(key string, value any) error {
+		walked = append(walked, key)
+		return nil
+	})
+	if !reflect.DeepEqual(walked, []string{composed}) {
+		t.Errorf("expected range to walk %q, got %q", composed, walked)
+	}
+	walked = nil
+	trie.WalkBetweenPrefixes(decomposed, decomposed, func(key string, value any) error {
+		walked = append(walked, key)
+		return nil
+	})
+	if !reflect.DeepEqual(walked, []string{composed}) {
+		t.Errorf("expected prefixes to walk %q, got %q", composed, walked)
+	}
+
+	if deleted := trie.Delete(decomposed); !deleted {
+		t.Error("expected decomposed key to be deleted")
+	}
+	if value, ok := trie.Get(composed); ok {
+		t.Errorf("expected composed key to be deleted, got value %v", value)
+	}
+}
+
+func TestRuneTrieFuzzySearch(t *testing.T) {
+	trie := NewRuneTrie[any]()
+	for _, word := range []string{"at", "act", "bat", "cat", "cats", "cut", "dog", "scatter", "ñat"} {
+		trie.Put(word, true)
+	}
+	cases := []struct {
+		query   string
+		maxDist int
+		keys    []string
+	}{
+		{"cat", 0, []string{"cat"}},
+		{"cat", 1, []string{"at", "bat", "cat", "cats", "cut", "ñat"}},
+		{"cat", 2, []string{"act", "at", "bat", "cat", "cats", "cut", "ñat"}},
+		{"dgo", 1, nil},
+		{"dgo", 2, []string{"dog"}},
+		{"", 2, []string{"at"}},
+		{"cat", -1, nil},
+	}
+	for _, c := range cases {
+		keys := trie.FuzzySearch(c.query, c.maxDist)
+		if !reflect.DeepEqual(keys, c.keys) {
+			t.Errorf("expected keys within %d of %s to be %v, got %v", c.maxDist, c.query, c.keys, keys)
+		}
+	}
+}
+
 // path trie
 
 func TestPathTrie(t *testing.T) {
@@ -90,225 +525,2793 @@ func TestPathTrieRoot(t *testing.T) {
 	if !pathTrie.isLeaf() {
 		t.Error("root should not have children, only value")
 	}
-}
+}
+
+func TestPathTrieWalk(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieWalk(t, trie)
+}
+
+func TestPathTrieWalkError(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieWalkError(t, trie)
+}
+
+func TestPathTrieWalkReentrant(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieWalkReentrant(t, trie)
+}
+
+func TestPathTrieWalkPostOrder(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieWalkPostOrder(t, trie)
+}
+
+func TestPathTrieParent(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieParent(t, trie)
+}
+
+func TestPathTrieWalkGrouped(t *testing.T) {
+	trie := NewPathTrie[any]()
+	for _, key := range []string{"", "/a", "/b", "/a/x", "/a/y", "/a/z/q", "/c/d"} {
+		trie.Put(key, key)
+	}
+	expected := []groupedWalk{
+		{"", []KeyValue[any]{{"/a", "/a"}, {"/b", "/b"}}},
+		{"/a", []KeyValue[any]{{"/a/x", "/a/x"}, {"/a/y", "/a/y"}}},
+		{"/a/z", []KeyValue[any]{{"/a/z/q", "/a/z/q"}}},
+		{"/c", []KeyValue[any]{{"/c/d", "/c/d"}}},
+	}
+	testTrieWalkGrouped(t, trie, expected)
+}
+
+func TestPathTriePutRef(t *testing.T) {
+	testTriePutRef(t, NewPathTrie[[]int]())
+}
+
+func TestPathTrieWalkWithMetrics(t *testing.T) {
+	trie := NewPathTrie[any]()
+	for _, key := range []string{"", "/cat", "/cat/gideon/paw", "/dog/rex"} {
+		trie.Put(key, key)
+	}
+	// root, /cat, /gideon, /paw, /dog, /rex
+	expected := WalkMetrics{NodesVisited: 6, ValuesDelivered: 4, MaxDepth: 3}
+	testTrieWalkWithMetrics(t, trie, expected)
+}
+
+func TestPathTrieIsEmpty(t *testing.T) {
+	testTrieIsEmpty(t, NewPathTrie[any]())
+}
+
+func TestPathTrieWalkLeaves(t *testing.T) {
+	testTrieWalkLeaves(t, NewPathTrie[any]())
+}
+
+func TestPathTrieWalkBetweenPrefixes(t *testing.T) {
+	testTrieWalkBetweenPrefixes(t, NewPathTrie[any](), nil)
+	// prefixes are normalized like keys
+	testTrieWalkBetweenPrefixes(t, NewPathTrie(WithKeyNormalizer[any](strings.ToLower)), strings.ToUpper)
+}
+
+func TestPathTrieNil(t *testing.T) {
+	var trie *pathTrie[any]
+	testTrieNil(t, trie)
+}
+
+func TestPathTrieWalkDelete(t *testing.T) {
+	testTrieWalkDelete(t, NewPathTrie[int]())
+}
+
+func TestPathTrieGetWithDepth(t *testing.T) {
+	trie := NewPathTrie[any]()
+	for _, key := range []string{"", "/api", "/api/users", "/api/users/admin"} {
+		trie.Put(key, key)
+	}
+	cases := map[string]int{"": 0, "/api": 1, "/api/users": 2, "/api/users/admin": 3}
+	for key, expected := range cases {
+		if value, depth, ok := trie.GetWithDepth(key); !ok || value != key || depth != expected {
+			t.Errorf("expected key %s to have value %s at depth %d, got (%v, %d, %t)", key, key, expected, value, depth, ok)
+		}
+	}
+	if value, depth, ok := trie.GetWithDepth("/api/posts"); ok || depth != 0 {
+		t.Errorf("expected key /api/posts to be missing, got (%v, %d, %t)", value, depth, ok)
+	}
+}
+
+func TestPathTrieWalkDeadline(t *testing.T) {
+	testTrieWalkDeadline(t, NewPathTrie[any]())
+}
+
+func TestPathTrieNumInternalNodes(t *testing.T) {
+	trie := NewPathTrie[any]()
+	if count := trie.NumInternalNodes(); count != 0 {
+		t.Errorf("expected 0 internal nodes, got %d", count)
+	}
+	for _, key := range []string{"/cat", "/cat/gideon/paw", "/dog/rex", "/dog/fido"} {
+		trie.Put(key, key)
+	}
+	// /cat/gideon, /dog
+	if count := trie.NumInternalNodes(); count != 2 {
+		t.Errorf("expected 2 internal nodes, got %d", count)
+	}
+	trie.Delete("/cat")
+	if count := trie.NumInternalNodes(); count != 3 {
+		t.Errorf("expected 3 internal nodes, got %d", count)
+	}
+}
+
+func TestPathTrieBranchingHistogram(t *testing.T) {
+	trie := NewPathTrie[any]()
+	if histogram := trie.BranchingHistogram(); len(histogram) != 0 {
+		t.Errorf("expected empty histogram, got %v", histogram)
+	}
+	for _, key := range []string{"/cat", "/cat/gideon/paw", "/dog/rex", "/dog/fido"} {
+		trie.Put(key, key)
+	}
+	// root: /cat, /dog; /cat: /gideon; /cat/gideon: /paw; /dog: /rex, /fido
+	expected := map[int]int{2: 2, 1: 2}
+	if histogram := trie.BranchingHistogram(); !reflect.DeepEqual(histogram, expected) {
+		t.Errorf("expected histogram %v, got %v", expected, histogram)
+	}
+}
+
+func TestPathTrieWalkLimit(t *testing.T) {
+	testTrieWalkLimit(t, NewPathTrie[any]())
+}
+
+func TestPathTrieWalkCollectErrors(t *testing.T) {
+	testTrieWalkCollectErrors(t, NewPathTrie[any]())
+}
+
+func TestPathTrieAncestors(t *testing.T) {
+	testTrieAncestors(t, NewPathTrie[any]())
+}
+
+func TestPathTrieExplain(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieExplain(t, trie, map[string]string{
+		"/a/b":   `found a value at "/a/b"`,
+		"/a/c":   `matched up to "/a", no child "/c"`,
+		"/a":     `matched "/a", but it holds no value`,
+		"x":      `no child "x" of the root`,
+		"/a/b/c": `matched up to "/a/b", no child "/c"`,
+	})
+	trie.PutTombstone("/a")
+	if explanation, expected := trie.Explain("/a"), `matched "/a", but it holds a tombstone`; explanation != expected {
+		t.Errorf("expected key /a to be explained as %s, got %s", expected, explanation)
+	}
+}
+
+func TestPathTrieDeleteAll(t *testing.T) {
+	testTrieDeleteAll(t, func() CommonTrie[int] { return NewPathTrie[int]() })
+	testTrieDeleteAll(t, func() CommonTrie[int] { return NewPathTrie(WithoutDeleteCleanup[int]()) })
+}
+
+func TestPathTrieWalkTree(t *testing.T) {
+	testTrieWalkTree(t, NewPathTrie[any](), []string{"/a", "/a/b", "/a/c/d", "/e"}, []treeNode{
+		{"", nil, true, 0},
+		{"/a", "/a", false, 1},
+		{"/a/b", "/a/b", false, 2},
+		{"/a/c", nil, true, 2},
+		{"/a/c/d", "/a/c/d", true, 3},
+		{"/e", "/e", true, 1},
+	})
+}
+
+func TestPathTrieSample(t *testing.T) {
+	testTrieSample(t, NewPathTrie[any]())
+}
+
+func TestPathTrieKeysToDepth(t *testing.T) {
+	testTrieKeysToDepth(t, NewPathTrie[any](), []string{"", "/a", "/a/b", "/a/b/c", "/d/e"}, map[int][]string{
+		-1: nil,
+		0:  {""},
+		1:  {"", "/a"},
+		2:  {"", "/a", "/a/b", "/d/e"},
+		3:  {"", "/a", "/a/b", "/a/b/c", "/d/e"},
+	})
+}
+
+func TestPathTrieMinMaxKey(t *testing.T) {
+	// segment "/a" sorts before segment "/a!"
+	testTrieMinMaxKey(t, NewPathTrie[any](), []string{"/a!", "/a/b", "/b", "/b/c/d"}, []string{"/0", "/z/y"}, "/a/b", "/b/c/d")
+}
+
+func TestPathTrieNearestByPrefix(t *testing.T) {
+	testTrieNearestByPrefix(t, NewPathTrie[any](), []string{"/a/b/c", "/a/d", "/x", "/x/y"}, "/a/e/f", map[string]string{
+		"/a/b/c":     "/a/b/c",
+		"/a/b/z":     "/a/b/c",
+		"/a/z":       "/a/b/c",
+		"/a/d/e":     "/a/d",
+		"/x/z":       "/x",
+		"/x/y/z":     "/x/y",
+		"/a/e/f/g":   "/a/b/c",
+		"/q":         "/a/b/c",
+		"/a/dd":      "/a/b/c",
+		"":           "/a/b/c",
+		"/x/y/z/w/v": "/x/y",
+	})
+}
+
+func TestPathTrieWalkWithChildCount(t *testing.T) {
+	testTrieWalkWithChildCount(t, NewPathTrie[any](), []string{"", "/a", "/a/b", "/a/c/d", "/e"}, map[string]int{
+		"":       2,
+		"/a":     2,
+		"/a/b":   0,
+		"/a/c/d": 0,
+		"/e":     0,
+	})
+}
+
+func TestPathTrieRekey(t *testing.T) {
+	testTrieRekey(t, NewPathTrie[int]())
+}
+
+func TestPathTrieWalkPath(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieWalkPath(t, trie)
+}
+
+func TestPathTrieWalkPathRemainder(t *testing.T) {
+	testTrieWalkPathRemainder(t, NewPathTrie[any]())
+}
+
+func TestPathTrieWalkPathError(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieWalkPathError(t, trie)
+}
+
+func TestPathTrieCommonPrefix(t *testing.T) {
+	testTrieCommonPrefix(t, func() CommonTrie[any] { return NewPathTrie[any]() }, []commonPrefixCase{
+		{[]string{}, ""},
+		{[]string{"/notes"}, "/notes"},
+		{[]string{"/notes/new/a", "/notes/new/b"}, "/notes/new"},
+		{[]string{"/notes/new", "/notes/nose"}, "/notes"},
+		{[]string{"/cat", "/cat/gideon", "/cat/giddy"}, "/cat"},
+		{[]string{"/cat", "/cats"}, ""},
+		{[]string{"", "/cat"}, ""},
+	})
+}
+
+func TestPathTriePrefixSeq(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTriePrefixSeq(t, trie)
+}
+
+func TestPathTrieLoadLines(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieLoadLines(t, trie)
+}
+
+func TestPathTrieWalkRange(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieWalkRange(t, trie, nil)
+	// bounds are normalized like keys
+	trie = NewPathTrie(WithKeyNormalizer[any](strings.ToLower))
+	testTrieWalkRange(t, trie, strings.ToUpper)
+
+	// path tries order keys segment by segment
+	trie = NewPathTrie[any]()
+	trie.Put("/a/b", 1)
+	trie.Put("/a-b", 2)
+	var keys []string
+	trie.WalkRange("/a", "/b", func(key string, value any) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if expected := []string{"/a/b", "/a-b"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+}
+
+func TestPathTrieWalkMutate(t *testing.T) {
+	trie := NewPathTrie[int]()
+	testTrieWalkMutate(t, trie)
+}
+
+func TestPathTrieDeepestKey(t *testing.T) {
+	testTrieDeepestKey(t, func() CommonTrie[any] { return NewPathTrie[any]() }, []deepestKeyCase{
+		{[]string{}, []string{""}, 0},
+		{[]string{""}, []string{""}, 0},
+		{[]string{"/cat", "/caterpillar", "/dog"}, []string{"/cat", "/caterpillar", "/dog"}, 1},
+		{[]string{"/notes", "/notes/new/noise", "/notes/:id", "/a/b"}, []string{"/notes/new/noise"}, 3},
+		{[]string{"/a/b/c/d", "/a/b", "/z"}, []string{"/a/b/c/d"}, 4},
+	})
+}
+
+func TestPathTrieReplaceSubtree(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieReplaceSubtree(t, trie, NewPathTrie[any]())
+}
+
+func TestPathTrieDeletePrefix(t *testing.T) {
+	testTrieDeletePrefix(t, func() CommonTrie[any] { return NewPathTrie[any]() }, map[string][]string{
+		"":          {"", "/cat", "/config", "/config/a", "/config/a/b", "/config/c"},
+		"/c":        nil,
+		"/config/a": {"/config/a", "/config/a/b"},
+		"/config":   {"/config", "/config/a", "/config/a/b", "/config/c"},
+		"/dog":      nil,
+	})
+}
+
+func TestPathTrieTopLevelCount(t *testing.T) {
+	trie := NewPathTrie[any]()
+	if count := trie.TopLevelCount(); count != 0 {
+		t.Errorf("expected empty trie to have 0 top level segments, got %d", count)
+	}
+	for _, key := range []string{"", "/cat", "/cat/gideon", "/dog", "/dog/rex", "fish"} {
+		trie.Put(key, key)
+	}
+	if count := trie.TopLevelCount(); count != 3 {
+		t.Errorf("expected 3 top level segments, got %d", count)
+	}
+	trie.Delete("fish")
+	if count := trie.TopLevelCount(); count != 2 {
+		t.Errorf("expected 2 top level segments, got %d", count)
+	}
+}
+
+func TestPathTrieChildrenKeys(t *testing.T) {
+	trie := NewPathTrie[any]()
+	for _, key := range []string{"/cat/gideon", "/cat/mochi", "/cat/apollo/paw", "/dog", "fish"} {
+		trie.Put(key, key)
+	}
+	cases := map[string][]string{
+		"":                {"/cat", "/dog", "fish"},
+		"/cat":            {"/cat/apollo", "/cat/gideon", "/cat/mochi"},
+		"/cat/apollo":     {"/cat/apollo/paw"},
+		"/cat/apollo/paw": nil, // leaf
+		"/dog":            nil, // leaf
+		"/cow":            nil, // missing
+	}
+	for key, expected := range cases {
+		if keys := trie.ChildrenKeys(key); !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected key %q to have children keys %v, got %v", key, expected, keys)
+		}
+	}
+}
+
+func TestPathTrieTouch(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieTouch(t, trie)
+
+	pathTrie := trie.(*pathTrie[any])
+	node := pathTrie.child("/a").child("/b")
+	if node == nil {
+		t.Fatal("expected Touch to create nodes along key /a/b")
+	}
+	trie.Put("/a/b", 1)
+	if pathTrie.child("/a").child("/b") != node {
+		t.Error("expected Put to reuse the nodes created by Touch")
+	}
+}
+
+func TestPathTrieFind(t *testing.T) {
+	trie := NewPathTrie[any]()
+	testTrieFind(t, trie)
+}
+
+func TestPathTrieWalkSegments(t *testing.T) {
+	table := map[string][]string{
+		"":                 {},
+		"/notes":           {"/notes"},
+		"/notes/new":       {"/notes", "/new"},
+		"/notes/new/noise": {"/notes", "/new", "/noise"},
+		"/notes/:id":       {"/notes", "/:id"},
+		"/cat/gideon":      {"/cat", "/gideon"},
+		"fish":             {"fish"},
+	}
+	trie := NewPathTrie[string]()
+	for key := range table {
+		trie.Put(key, key)
+	}
+
+	walked := make(map[string][]string)
+	err := trie.WalkSegments(func(segments []string, value string) error {
+		// copy since the segments slice is reused
+		walked[value] = append([]string{}, segments...)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if !reflect.DeepEqual(walked, table) {
+		t.Errorf("expected walked segments %v, got %v", table, walked)
+	}
+
+	walkerError := errors.New("walker error")
+	err = trie.WalkSegments(func(segments []string, value string) error {
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+
+	// segments share one buffer, so a walk only allocates the buffer and its
+	// slice header beyond a walk reusing a buffer the caller allocated
+	noop := func(segments []string, value string) error { return nil }
+	buffer := make([]string, 0, walkSegmentsDepth)
+	root := trie.(*pathTrie[string])
+	reused := testing.AllocsPerRun(10, func() { root.walkSegments(&buffer, 0, noop) })
+	if allocs := testing.AllocsPerRun(10, func() { trie.WalkSegments(noop) }); allocs > reused+2 {
+		t.Errorf("expected at most %v allocations, got %v", reused+2, allocs)
+	}
+}
+
+func TestPathTrieSegmentPaths(t *testing.T) {
+	trie := NewPathTrie[any]()
+	cases := []struct {
+		key      string
+		segments []string
+		value    any
+	}{
+		{"", []string{}, -1},
+		{"/cat", []string{"/cat"}, 1},
+		{"/cat/gideon", []string{"/cat", "/gideon"}, 2},
+		{"/notes/new/noise", []string{"/notes", "/new", "/noise"}, 3},
+		{"fish", []string{"fish"}, 4},
+	}
+
+	// put by segments, get by key and by segments
+	for _, c := range cases {
+		if isNew := trie.PutPath(c.segments, c.value); !isNew {
+			t.Errorf("expected segments %v to be missing", c.segments)
+		}
+	}
+	for _, c := range cases {
+		if value, ok := trie.Get(c.key); !ok || value != c.value {
+			t.Errorf("expected key %s to have value %v, got %v", c.key, c.value, value)
+		}
+		if value, ok := trie.GetPath(c.segments); !ok || value != c.value {
+			t.Errorf("expected segments %v to have value %v, got %v", c.segments, c.value, value)
+		}
+	}
+	if value, ok := trie.GetPath([]string{"/notes", "/new"}); ok {
+		t.Errorf("expected internal segments to be missing, got value %v", value)
+	}
+
+	// put by key replaces values put by segments
+	for _, c := range cases {
+		if isNew := trie.Put(c.key, c.key); isNew {
+			t.Errorf("expected key %s to have a value already", c.key)
+		}
+	}
+
+	// delete by segments cleans up nodes like delete by key
+	for _, c := range cases {
+		if deleted := trie.DeletePath(c.segments); !deleted {
+			t.Errorf("expected segments %v to be deleted", c.segments)
+		}
+		if value, ok := trie.Get(c.key); ok {
+			t.Errorf("expected key %s to be deleted, got value %v", c.key, value)
+		}
+	}
+	if deleted := trie.DeletePath([]string{"/notes"}); deleted {
+		t.Error("expected segments [/notes] to be cleaned by delete")
+	}
+	if !trie.(*pathTrie[any]).isLeaf() {
+		t.Error("expected root to be a leaf after deleting all segments")
+	}
+}
+
+func TestPathTrieWithSegmentTransform(t *testing.T) {
+	unescape := func(segment string) string {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			return unescaped
+		}
+		return segment
+	}
+	// the transform applies whether it is set before or after the segmenter
+	for _, trie := range []PathTrie[int]{
+		NewPathTrie(WithSegmentTransform[int](unescape)),
+		NewPathTrie(WithSegmentTransform[int](unescape), WithSegmenter[int](PathSegmenter)),
+	} {
+		if !trie.Put("/a%20b/c", 1) {
+			t.Error("expected put of new key /a%20b/c")
+		}
+		if trie.Put("/a b/c", 2) {
+			t.Error("expected put of key /a b/c to replace key /a%20b/c")
+		}
+		if value, ok := trie.Get("/a%20b/%63"); !ok || value != 2 {
+			t.Errorf("expected key /a%%20b/%%63 to have value 2, got %d", value)
+		}
+		var keys []string
+		trie.Walk(func(key string, value int) error {
+			keys = append(keys, key)
+			return nil
+		})
+		if expected := []string{"/a b/c"}; !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected keys %v, got %v", expected, keys)
+		}
+		if !trie.Delete("/a%20b/c") || !trie.IsEmpty() {
+			t.Error("expected key /a%20b/c to be deleted")
+		}
+	}
+
+	// lookups along a key report the transformed keys Walk reports
+	trie := NewPathTrie(WithSegmentTransform[int](unescape))
+	trie.Put("/a%20b", 1)
+	trie.Put("/a%20b/c", 2)
+	walked := make(map[string]int)
+	trie.Walk(func(key string, value int) error {
+		walked[key] = value
+		return nil
+	})
+	if expected := map[string]int{"/a b": 1, "/a b/c": 2}; !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected walked keys %v, got %v", expected, walked)
+	}
+	if parent, _, _ := trie.Parent("/a%20b/c"); parent != "/a b" {
+		t.Errorf("expected parent /a b, got %q", parent)
+	}
+	var path, remainderPath []string
+	trie.WalkPath("/a%20b/c", func(key string, value int) error {
+		path = append(path, key)
+		return nil
+	})
+	trie.WalkPathRemainder("/a%20b/c", func(key string, value int, remainder string) error {
+		remainderPath = append(remainderPath, key)
+		return nil
+	})
+	for _, keys := range [][]string{path, remainderPath} {
+		if expected := []string{"/a b", "/a b/c"}; !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected path keys %v, got %v", expected, keys)
+		}
+		for _, key := range keys {
+			if _, ok := walked[key]; !ok {
+				t.Errorf("expected path key %q to be walked", key)
+			}
+		}
+	}
+	if explained, expected := trie.Explain("/a%20b/c"), `found a value at "/a b/c"`; explained != expected {
+		t.Errorf("expected explanation %q, got %q", expected, explained)
+	}
+	if explained, expected := trie.Explain("/a%20b/d"), `matched up to "/a b", no child "/d"`; explained != expected {
+		t.Errorf("expected explanation %q, got %q", expected, explained)
+	}
+}
+
+func TestPathTrieWithKeyNormalizer(t *testing.T) {
+	trie := NewPathTrie(WithKeyNormalizer[any](strings.ToLower))
+	if isNew := trie.Put("/Cat/Gideon", 1); !isNew {
+		t.Error("expected key /Cat/Gideon to be missing")
+	}
+	if isNew := trie.Put("/CAT/gideon", 2); isNew {
+		t.Error("expected key /CAT/gideon to normalize to an existing key")
+	}
+	trie.Put("/Dog", 3)
+
+	for _, key := range []string{"/cat/gideon", "/Cat/Gideon", "/CAT/GIDEON"} {
+		if value, ok := trie.Get(key); !ok || value != 2 {
+			t.Errorf("expected key %s to have value 2, got %v", key, value)
+		}
+	}
+
+	// walks return normalized keys
+	var keys []string
+	trie.Walk(func(key string, value any) error {
+		keys = append(keys, key)
+		return nil
+	})
+	sort.Strings(keys)
+	if expected := []string{"/cat/gideon", "/dog"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected walked keys %v, got %v", expected, keys)
+	}
+
+	if deleted := trie.Delete("/DOG"); !deleted {
+		t.Error("expected key /DOG to be deleted")
+	}
+	if value, ok := trie.Get("/dog"); ok {
+		t.Errorf("expected key /dog to be deleted, got value %v", value)
+	}
+
+	// prefixes are normalized like keys
+	trie.Put("/cat/tom", 3)
+	var prefixed []string
+	for key := range trie.PrefixSeq("/CAT") {
+		prefixed = append(prefixed, key)
+	}
+	sort.Strings(prefixed)
+	if expected := []string{"/cat/gideon", "/cat/tom"}; !reflect.DeepEqual(prefixed, expected) {
+		t.Errorf("expected keys under /CAT %v, got %v", expected, prefixed)
+	}
+	replacement := NewPathTrie[any]()
+	replacement.Put("/Felix", 4)
+	if delta := trie.ReplaceSubtree("/CAT", replacement); delta != -1 {
+		t.Errorf("expected net change -1, got %d", delta)
+	}
+	if value, ok := trie.Get("/cat/gideon"); ok {
+		t.Errorf("expected key /cat/gideon to be replaced, got %v", value)
+	}
+	if value, ok := trie.Get("/cat/felix"); !ok || value != 4 {
+		t.Errorf("expected key /cat/felix to have value 4, got %v", value)
+	}
+}
+
+func TestPathTrieWithMaxEntries(t *testing.T) {
+	var evicted []KeyValue[int]
+	trie := NewPathTrie(WithMaxEntries(3, func(key string, value int) {
+		evicted = append(evicted, KeyValue[int]{Key: key, Value: value})
+	}))
+	trie.Put("/a", 1)
+	trie.Put("/a/b", 2)
+	trie.Put("/c", 3)
+	// replacing a value keeps its position
+	trie.Put("/a", 10)
+	if len(evicted) != 0 {
+		t.Errorf("expected no evictions, got %v", evicted)
+	}
+
+	trie.Put("/d", 4)
+	trie.Put("/e", 5)
+	if expected := []KeyValue[int]{{"/a", 10}, {"/a/b", 2}}; !reflect.DeepEqual(evicted, expected) {
+		t.Errorf("expected evictions %v, got %v", expected, evicted)
+	}
+	if nodes := nodeKeys(trie, ""); !reflect.DeepEqual(nodes, []string{"/c", "/d", "/e"}) {
+		t.Errorf("expected evicted nodes to be removed, got nodes %v", nodes)
+	}
+
+	// deleted keys no longer count towards the bound
+	evicted = nil
+	trie.Delete("/c")
+	trie.Put("/f", 6)
+	if len(evicted) != 0 {
+		t.Errorf("expected no evictions, got %v", evicted)
+	}
+	trie.Put("/c", 7)
+	if expected := []KeyValue[int]{{"/d", 4}}; !reflect.DeepEqual(evicted, expected) {
+		t.Errorf("expected evictions %v, got %v", expected, evicted)
+	}
+	if _, ok := trie.Get("/c"); !ok {
+		t.Error("expected re-inserted key /c to be kept")
+	}
+
+	// a nil callback still evicts
+	trie = NewPathTrie(WithMaxEntries[int](1, nil))
+	trie.Put("/a", 1)
+	trie.Put("/b", 2)
+	if _, ok := trie.Get("/a"); ok {
+		t.Error("expected key /a to be evicted")
+	}
+
+	// PutPath and SortedBuilder values count towards the bound
+	trie = NewPathTrie(WithMaxEntries[int](1, nil))
+	trie.PutPath([]string{"/a", "/b"}, 1)
+	trie.PutPath([]string{"/c"}, 2)
+	if _, ok := trie.Get("/a/b"); ok {
+		t.Error("expected key /a/b to be evicted")
+	}
+	builder := NewSortedBuilder(WithMaxEntries[int](2, nil))
+	for i, key := range []string{"/a", "/b", "/c"} {
+		builder.Add(key, i)
+	}
+	if keys := builder.Build().KeysToDepth(1); !reflect.DeepEqual(keys, []string{"/b", "/c"}) {
+		t.Errorf("expected built trie to keep [/b /c], got %v", keys)
+	}
+
+	// evicts keys whose segments the segmenter would not reproduce
+	evicted = nil
+	trie = NewPathTrie(WithMaxEntries(1, func(key string, value int) {
+		evicted = append(evicted, KeyValue[int]{Key: key, Value: value})
+	}))
+	trie.PutPath([]string{"a", "b"}, 1)
+	trie.PutPath([]string{"c"}, 2)
+	if expected := []KeyValue[int]{{"ab", 1}}; !reflect.DeepEqual(evicted, expected) {
+		t.Errorf("expected evictions %v, got %v", expected, evicted)
+	}
+	if nodes := nodeKeys(trie, ""); !reflect.DeepEqual(nodes, []string{"c"}) {
+		t.Errorf("expected evicted nodes to be removed, got nodes %v", nodes)
+	}
+
+	// evicts keys stored with transformed segments
+	evicted = nil
+	mark := func(segment string) string { return segment + "!" }
+	trie = NewPathTrie(WithSegmentTransform[int](mark), WithMaxEntries(1, func(key string, value int) {
+		evicted = append(evicted, KeyValue[int]{Key: key, Value: value})
+	}))
+	trie.Put("/a/b", 1)
+	trie.Put("/c", 2)
+	if expected := []KeyValue[int]{{"/a!/b!", 1}}; !reflect.DeepEqual(evicted, expected) {
+		t.Errorf("expected evictions %v, got %v", expected, evicted)
+	}
+	if nodes := nodeKeys(trie, ""); !reflect.DeepEqual(nodes, []string{"/c!"}) {
+		t.Errorf("expected evicted nodes to be removed, got nodes %v", nodes)
+	}
+}
+
+func TestPathTrieWithInsertionOrder(t *testing.T) {
+	trie := NewPathTrie(WithInsertionOrder[int]())
+	for i, key := range []string{"/c", "/a/b", "", "/b", "/a", "/d"} {
+		trie.Put(key, i)
+	}
+	// replacing keeps position, re-inserting moves to the end
+	trie.Put("/a/b", 10)
+	trie.Delete("/b")
+	trie.Put("/b", 11)
+	trie.Delete("/d")
+	// deleting a subtree forgets its keys
+	trie.ReplaceSubtree("/a", NewPathTrie[int]())
+	trie.Put("/a/b", 12)
+
+	var keys []string
+	var values []int
+	err := trie.WalkInsertionOrder(func(key string, value int) error {
+		keys = append(keys, key)
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if expected := []string{"/c", "", "/b", "/a/b"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected insertion order %v, got %v", expected, keys)
+	}
+	if expected := []int{0, 2, 11, 12}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+	if tracked := len(trie.(*pathTrie[int]).config.insertionOrder); tracked != 4 {
+		t.Errorf("expected 4 tracked values, got %d", tracked)
+	}
+
+	walkerError := errors.New("walker error")
+	err = trie.WalkInsertionOrder(func(key string, value int) error {
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+
+	if err := NewPathTrie[int]().WalkInsertionOrder(nil); err != ErrNoInsertionOrder {
+		t.Errorf("expected ErrNoInsertionOrder, got %v", err)
+	}
+}
+
+func TestPathTrieWithoutDeleteCleanup(t *testing.T) {
+	trie := NewPathTrie(WithoutDeleteCleanup[int]())
+	trie.Put("/a/b/c", 1)
+	trie.Put("/a/d", 2)
+	trie.PutTombstone("/e/f")
+
+	// deleting leaves emptied nodes in place
+	node := trie.(*pathTrie[int]).child("/a").child("/b").child("/c")
+	if !trie.Delete("/a/b/c") {
+		t.Errorf("expected key /a/b/c to be deleted")
+	}
+	if value, ok := trie.Get("/a/b/c"); ok {
+		t.Errorf("expected key /a/b/c to be absent, got %v", value)
+	}
+	trie.Put("/a/b/c", 3)
+	if trie.(*pathTrie[int]).child("/a").child("/b").child("/c") != node {
+		t.Errorf("expected key /a/b/c to reuse its node")
+	}
+
+	// prune removes emptied nodes, keeping values and tombstones
+	trie.Delete("/a/b/c")
+	trie.Delete("/a/d")
+	trie.Touch("/g/h")
+	if pruned := trie.Prune(); pruned != 6 {
+		t.Errorf("expected 6 nodes pruned, got %d", pruned)
+	}
+	if root := trie.(*pathTrie[int]); root.numChildren() != 1 || root.child("/e") == nil {
+		t.Errorf("expected only node /e to remain, got %v", root.ChildrenKeys(""))
+	}
+	if _, entry := trie.GetEntry("/e/f"); entry != EntryTombstone {
+		t.Errorf("expected key /e/f to be a tombstone, got %v", entry)
+	}
+	if pruned := trie.Prune(); pruned != 0 {
+		t.Errorf("expected 0 nodes pruned, got %d", pruned)
+	}
+}
+
+func TestPathTrieWastedNodes(t *testing.T) {
+	trie := NewPathTrie(WithoutDeleteCleanup[int]())
+	trie.Put("/a/b/c", 1)
+	trie.Put("/a/d", 2)
+	trie.PutTombstone("/e/f")
+	if wasted := trie.WastedNodes(); wasted != 0 {
+		t.Errorf("expected 0 wasted nodes, got %d", wasted)
+	}
+	trie.Delete("/a/b/c")
+	if wasted := trie.WastedNodes(); wasted != 2 {
+		t.Errorf("expected 2 wasted nodes, got %d", wasted)
+	}
+	trie.Delete("/a/d")
+	trie.Touch("/g")
+	if wasted := trie.WastedNodes(); wasted != 5 {
+		t.Errorf("expected 5 wasted nodes, got %d", wasted)
+	}
+	if pruned := trie.Prune(); pruned != 5 {
+		t.Errorf("expected 5 nodes pruned, got %d", pruned)
+	}
+	if wasted := trie.WastedNodes(); wasted != 0 {
+		t.Errorf("expected 0 wasted nodes, got %d", wasted)
+	}
+}
+
+func TestPathTrieWithAutoCompact(t *testing.T) {
+	trie := NewPathTrie(WithoutDeleteCleanup[int](), WithAutoCompact[int](4))
+	trie.Put("/a/b/c", 1)
+	trie.Put("/d/e", 2)
+	trie.Put("/d/f", 3)
+	trie.Put("", 4)
+
+	// deletes leave dead nodes until the threshold is exceeded
+	trie.Delete("/a/b/c")
+	trie.Delete("/d/e")
+	trie.Delete("") // the root is never emptied
+	// deleting already emptied nodes empties nothing more
+	trie.Delete("/a/b/c")
+	trie.Delete("/d/e")
+	if wasted := trie.WastedNodes(); wasted != 4 {
+		t.Errorf("expected 4 wasted nodes, got %d", wasted)
+	}
+	trie.Delete("/d/f")
+	if wasted := trie.WastedNodes(); wasted != 0 {
+		t.Errorf("expected auto compaction to remove wasted nodes, got %d", wasted)
+	}
+	if root := trie.(*pathTrie[int]); root.children != nil {
+		t.Errorf("expected no nodes to remain, got %v", root.ChildrenKeys(""))
+	}
+
+	// the count of emptied nodes restarts after compaction
+	trie.Put("/x/y", 5)
+	trie.Delete("/x/y")
+	if wasted := trie.WastedNodes(); wasted != 2 {
+		t.Errorf("expected 2 wasted nodes, got %d", wasted)
+	}
+}
+
+func TestPathTrieWithWalkSeed(t *testing.T) {
+	var keys []string
+	for _, a := range []string{"/a", "/b", "/c", "/d", "/e"} {
+		for _, b := range []string{"/x", "/y", "/z"} {
+			keys = append(keys, a+b)
+		}
+	}
+	walkOrder := func(seed int64) []string {
+		// insert in a different order each time
+		trie := NewPathTrie(WithWalkSeed[int](seed))
+		for _, i := range rand.Perm(len(keys)) {
+			trie.Put(keys[i], i)
+		}
+		var walked []string
+		trie.Walk(func(key string, value int) error {
+			walked = append(walked, key)
+			return nil
+		})
+		return walked
+	}
+
+	first := walkOrder(42)
+	for i := 0; i < 5; i++ {
+		if walked := walkOrder(42); !reflect.DeepEqual(walked, first) {
+			t.Errorf("expected walks with the same seed to match, got %v and %v", first, walked)
+		}
+	}
+	if sort.StringsAreSorted(first) {
+		t.Errorf("expected seeded walk to not be sorted, got %v", first)
+	}
+	if walked := walkOrder(7); reflect.DeepEqual(walked, first) {
+		t.Errorf("expected walks with different seeds to differ, got %v", walked)
+	}
+}
+
+func TestPathTrieWithIterativeWalk(t *testing.T) {
+	walkKeys := func(trie PathTrie[int]) []string {
+		var walked []string
+		trie.Walk(func(key string, value int) error {
+			walked = append(walked, key)
+			return nil
+		})
+		sort.Strings(walked)
+		return walked
+	}
+	deep := strings.Repeat("/a", 5000)
+	cases := [][]string{
+		{},
+		{""},
+		{"", "/a", "/a/b", "/a/c", "/b", "/b/c/d"},
+		{"/x/y/z", "/x/y", "/x/w"},
+		{deep, deep[:5000], deep[:2]},
+	}
+	for _, keys := range cases {
+		recursive := NewPathTrie[int]()
+		iterative := NewPathTrie(WithIterativeWalk[int]())
+		for i, key := range keys {
+			recursive.Put(key, i)
+			iterative.Put(key, i)
+		}
+		if got, want := walkKeys(iterative), walkKeys(recursive); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected iterative walk to visit %v, got %v", want, got)
+		}
+	}
+
+	// walk order matches with a walk seed
+	recursive := NewPathTrie(WithWalkSeed[int](42))
+	iterative := NewPathTrie(WithWalkSeed[int](42), WithIterativeWalk[int]())
+	for i, key := range []string{"/a", "/a/b", "/a/c", "/b", "/c", "/c/d", "/c/e"} {
+		recursive.Put(key, i)
+		iterative.Put(key, i)
+	}
+	var want, got []string
+	recursive.Walk(func(key string, value int) error {
+		want = append(want, key)
+		return nil
+	})
+	iterative.Walk(func(key string, value int) error {
+		got = append(got, key)
+		return nil
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected seeded iterative walk order %v, got %v", want, got)
+	}
+
+	// walker errors abort the walk
+	var visited int
+	err := iterative.Walk(func(key string, value int) error {
+		visited++
+		return errors.New("stop")
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Errorf("expected walker error, got %v", err)
+	}
+	if visited != 1 {
+		t.Errorf("expected walk to stop after 1 key, got %d", visited)
+	}
+}
+
+func TestPathTrieToNestedMap(t *testing.T) {
+	trie := NewPathTrie[int]()
+	if m := trie.ToNestedMap(); !reflect.DeepEqual(m, map[string]any{}) {
+		t.Errorf("expected empty trie to have empty nested map, got %v", m)
+	}
+	trie.Put("", 0)
+	trie.Put("/cat", 1)
+	trie.Put("/cat/gideon", 2)
+	trie.Put("/dog/rex", 3)
+	trie.PutTombstone("/fish")
+
+	expected := map[string]any{
+		NestedMapValueKey: 0,
+		"/cat": map[string]any{
+			NestedMapValueKey: 1,
+			"/gideon":         map[string]any{NestedMapValueKey: 2},
+		},
+		"/dog": map[string]any{
+			"/rex": map[string]any{NestedMapValueKey: 3},
+		},
+		"/fish": map[string]any{},
+	}
+	if m := trie.ToNestedMap(); !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected nested map %v, got %v", expected, m)
+	}
+}
+
+func TestPathTrieWalkPathReport(t *testing.T) {
+	trie := NewPathTrie[int]()
+	trie.Put("/api/users/admin", 1)
+	trie.Put("/api/posts", 2)
+
+	cases := []struct {
+		key       string
+		matched   []string
+		unmatched string
+	}{
+		{"", nil, ""},
+		{"/api/users/admin", []string{"/api", "/users", "/admin"}, ""},
+		// internal nodes match without values
+		{"/api/users", []string{"/api", "/users"}, ""},
+		{"/api/users/guest/settings", []string{"/api", "/users"}, "/guest/settings"},
+		{"/api/posts/1", []string{"/api", "/posts"}, "/1"},
+		{"/static/app.js", nil, "/static/app.js"},
+	}
+	for _, c := range cases {
+		matched, unmatched := trie.WalkPathReport(c.key)
+		if !reflect.DeepEqual(matched, c.matched) || unmatched != c.unmatched {
+			t.Errorf("expected key %s to report (%v, %q), got (%v, %q)", c.key, c.matched, c.unmatched, matched, unmatched)
+		}
+	}
+}
+
+func TestPathTrieLongestPrefixSegments(t *testing.T) {
+	trie := NewPathTrie[int]()
+	trie.Put("/api", 1)
+	trie.Put("/api/users/admin", 2)
+
+	cases := []struct {
+		key      string
+		segments []string
+		value    int
+		ok       bool
+	}{
+		{"", nil, 0, false},
+		{"/api", []string{"/api"}, 1, true},
+		{"/api/users", []string{"/api"}, 1, true},
+		{"/api/users/admin", []string{"/api", "/users", "/admin"}, 2, true},
+		{"/api/users/admin/settings", []string{"/api", "/users", "/admin"}, 2, true},
+		{"/static/app.js", nil, 0, false},
+	}
+	for _, c := range cases {
+		segments, value, ok := trie.LongestPrefixSegments(c.key)
+		if !reflect.DeepEqual(segments, c.segments) || value != c.value || ok != c.ok {
+			t.Errorf("expected key %s to match (%v, %d, %t), got (%v, %d, %t)", c.key, c.segments, c.value, c.ok, segments, value, ok)
+		}
+		// the segments reconstruct the matched prefix
+		if prefix := strings.Join(segments, ""); !strings.HasPrefix(c.key, prefix) {
+			t.Errorf("expected segments %v to join to a prefix of %s", segments, c.key)
+		}
+	}
+
+	// the root matches with no segments
+	trie.Put("", 0)
+	if segments, value, ok := trie.LongestPrefixSegments("/static"); len(segments) != 0 || value != 0 || !ok {
+		t.Errorf("expected root match, got (%v, %d, %t)", segments, value, ok)
+	}
+
+	// segments are returned as the segmenter returns them
+	folding := NewPathTrie(WithSegmenter[int](foldingSegmenter))
+	folding.Put("/Users/Alice", 1)
+	segments, _, ok := folding.LongestPrefixSegments("/USERS/ALICE/Docs")
+	if want := []string{"/users", "/alice"}; !ok || !reflect.DeepEqual(segments, want) {
+		t.Errorf("expected segments %v, got %v", want, segments)
+	}
+}
+
+func TestPathTrieWithReadCloner(t *testing.T) {
+	clone := func(s []int) []int { return append([]int(nil), s...) }
+	trie := NewPathTrie(WithReadCloner(clone))
+	trie.Put("/a", []int{1, 2})
+	trie.Put("/a/b", []int{3})
+
+	value, _ := trie.Get("/a")
+	value[0] = 10
+	trie.Walk(func(key string, value []int) error {
+		value[0] = 10
+		return nil
+	})
+	trie.WalkPath("/a/b", func(key string, value []int) error {
+		value[0] = 10
+		return nil
+	})
+	segmented, _ := trie.GetPath([]string{"/a", "/b"})
+	segmented[0] = 10
+	if value, _ := trie.Get("/a"); !reflect.DeepEqual(value, []int{1, 2}) {
+		t.Errorf("expected key /a to have value [1 2], got %v", value)
+	}
+	if value, _ := trie.Get("/a/b"); !reflect.DeepEqual(value, []int{3}) {
+		t.Errorf("expected key /a/b to have value [3], got %v", value)
+	}
+
+	// every other reader hands out copies too
+	trie = NewPathTrie(WithReadCloner(clone), WithInsertionOrder[[]int](), WithDirtyTracking[[]int]())
+	trie.Put("/a", []int{1, 2})
+	trie.Put("/a/b", []int{3})
+	mutate := func(key string, value []int) error {
+		value[0] = 10
+		return nil
+	}
+	readers := map[string]func(){
+		"Find": func() {
+			_, value, _ := trie.Find(func(key string, value []int) bool { return key == "/a" })
+			value[0] = 10
+		},
+		"Parent": func() {
+			_, value, _ := trie.Parent("/a/b")
+			value[0] = 10
+		},
+		"GetEntry": func() {
+			value, _ := trie.GetEntry("/a")
+			value[0] = 10
+		},
+		"NearestByPrefix": func() {
+			_, value, _ := trie.NearestByPrefix("/a/c")
+			value[0] = 10
+		},
+		"LongestPrefixSegments": func() {
+			_, value, _ := trie.LongestPrefixSegments("/a/b/c")
+			value[0] = 10
+		},
+		"Ancestors": func() {
+			for _, kv := range trie.Ancestors("/a/b") {
+				kv.Value[0] = 10
+			}
+		},
+		"PrefixSeq": func() {
+			for _, value := range trie.PrefixSeq("/a") {
+				value[0] = 10
+			}
+		},
+		"ToNestedMap": func() {
+			nested := trie.ToNestedMap()["/a"].(map[string]any)
+			nested[NestedMapValueKey].([]int)[0] = 10
+		},
+		"WalkPathRemainder": func() {
+			trie.WalkPathRemainder("/a/b", func(key string, value []int, remainder string) error {
+				value[0] = 10
+				return nil
+			})
+		},
+		"WalkSegments": func() {
+			trie.WalkSegments(func(segments []string, value []int) error {
+				value[0] = 10
+				return nil
+			})
+		},
+		"WalkGrouped": func() {
+			trie.WalkGrouped(func(parentKey string, children []KeyValue[[]int]) error {
+				for _, kv := range children {
+					kv.Value[0] = 10
+				}
+				return nil
+			})
+		},
+		"WalkTree": func() {
+			trie.WalkTree(func(key string, value []int, isLast bool, depth int) error {
+				if value != nil {
+					value[0] = 10
+				}
+				return nil
+			})
+		},
+		"WalkWithChildCount": func() {
+			trie.WalkWithChildCount(func(key string, value []int, childCount int) error {
+				value[0] = 10
+				return nil
+			})
+		},
+		"WalkDelete": func() {
+			trie.WalkDelete(func(key string, value []int) (bool, error) {
+				value[0] = 10
+				return false, nil
+			})
+		},
+		"WalkFiltered": func() {
+			trie.WalkFiltered(func(segment string, depth int) bool { return true }, mutate)
+		},
+		"WalkPostOrder":       func() { trie.WalkPostOrder(mutate) },
+		"WalkLeaves":          func() { trie.WalkLeaves(mutate) },
+		"WalkRange":           func() { trie.WalkRange("/a", "/b", mutate) },
+		"WalkBetweenPrefixes": func() { trie.WalkBetweenPrefixes("/a", "/b", mutate) },
+		"WalkInsertionOrder":  func() { trie.WalkInsertionOrder(mutate) },
+		"WalkDirty":           func() { trie.WalkDirty(mutate) },
+		"WalkGlob":            func() { trie.WalkGlob("/*", mutate) },
+		"WalkLimit":           func() { trie.WalkLimit(2, mutate) },
+		"WalkWithMetrics":     func() { trie.WalkWithMetrics(mutate) },
+	}
+	for name, read := range readers {
+		read()
+		if value, _ := trie.Get("/a"); !reflect.DeepEqual(value, []int{1, 2}) {
+			t.Errorf("%s: expected key /a to have value [1 2], got %v", name, value)
+		}
+		if value, _ := trie.Get("/a/b"); !reflect.DeepEqual(value, []int{3}) {
+			t.Errorf("%s: expected key /a/b to have value [3], got %v", name, value)
+		}
+	}
+
+	// without a cloner, callers share the stored value
+	trie = NewPathTrie[[]int]()
+	trie.Put("/a", []int{1, 2})
+	value, _ = trie.Get("/a")
+	value[0] = 10
+	if value, _ := trie.Get("/a"); !reflect.DeepEqual(value, []int{10, 2}) {
+		t.Errorf("expected key /a to have value [10 2], got %v", value)
+	}
+}
+
+func TestPathTrieWalkFiltered(t *testing.T) {
+	trie := NewPathTrie[int]()
+	keys := []string{"", "/src", "/src/main.go", "/vendor/lib/lib.go", "/docs/vendor/index.md", "/.git/HEAD"}
+	for i, key := range keys {
+		trie.Put(key, i)
+	}
+	var visited []string
+	keep := func(segment string, depth int) bool {
+		visited = append(visited, fmt.Sprintf("%s@%d", segment, depth))
+		// skip top level vendored and hidden directories
+		return depth > 1 || (segment != "/vendor" && !strings.HasPrefix(segment, "/."))
+	}
+	walked := make(map[string]int)
+	err := trie.WalkFiltered(keep, func(key string, value int) error {
+		walked[key] = value
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	expected := map[string]int{"": 0, "/src": 1, "/src/main.go": 2, "/docs/vendor/index.md": 4}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected keys %v, got %v", expected, walked)
+	}
+	// pruned subtrees are never visited
+	sort.Strings(visited)
+	expectedVisited := []string{"/.git@1", "/docs@1", "/index.md@3", "/main.go@2", "/src@1", "/vendor@1", "/vendor@2"}
+	if !reflect.DeepEqual(visited, expectedVisited) {
+		t.Errorf("expected segments %v to be visited, got %v", expectedVisited, visited)
+	}
+
+	walkerError := errors.New("walker error")
+	err = trie.WalkFiltered(func(string, int) bool { return true }, func(key string, value int) error {
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+}
+
+func TestPathTrieWithDefault(t *testing.T) {
+	trie := NewPathTrie(WithDefault("unset"))
+	trie.Put("/config/timeout", "30s")
+	trie.Put("/config/retries", "")
+
+	cases := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"/config/timeout", "30s", true},
+		// stored zero values are hits
+		{"/config/retries", "", true},
+		{"/config", "unset", false},
+		{"/config/timeout/ms", "unset", false},
+		{"/other", "unset", false},
+	}
+	for _, c := range cases {
+		if value, ok := trie.Get(c.key); value != c.value || ok != c.ok {
+			t.Errorf("expected Get(%q) to return (%q, %t), got (%q, %t)", c.key, c.value, c.ok, value, ok)
+		}
+	}
+	if value, ok := trie.GetPath([]string{"/config", "/missing"}); value != "unset" || ok {
+		t.Errorf("expected GetPath miss to return the default, got (%q, %t)", value, ok)
+	}
+	trie.PutTombstone("/config/removed")
+	if value, ok := trie.Get("/config/removed"); value != "unset" || ok {
+		t.Errorf("expected tombstone to return the default, got (%q, %t)", value, ok)
+	}
+
+	// loader misses return the default
+	loading := NewPathTrie(WithDefault(-1), WithLoader(func(key string) (int, bool) {
+		return len(key), key != "/missing"
+	}))
+	if value, ok := loading.Get("/found"); value != 6 || !ok {
+		t.Errorf("expected loaded value 6, got (%d, %t)", value, ok)
+	}
+	if value, ok := loading.Get("/missing"); value != -1 || ok {
+		t.Errorf("expected default -1 for loader miss, got (%d, %t)", value, ok)
+	}
+}
+
+func TestPathTrieWithLoader(t *testing.T) {
+	loads := make(map[string]int)
+	loader := func(key string) (int, bool) {
+		loads[key]++
+		if key == "/missing" {
+			return 0, false
+		}
+		return len(key), true
+	}
+	trie := NewPathTrie(WithLoader(loader))
+	trie.Put("/cat", 1)
+
+	// hit does not call the loader
+	if value, ok := trie.Get("/cat"); !ok || value != 1 {
+		t.Errorf("expected key /cat to have value 1, got %v", value)
+	}
+	if loads["/cat"] != 0 {
+		t.Errorf("expected key /cat to not be loaded, got %d loads", loads["/cat"])
+	}
+
+	// miss calls the loader and caches the result
+	for i := 0; i < 2; i++ {
+		if value, ok := trie.Get("/cat/gideon"); !ok || value != 11 {
+			t.Errorf("expected key /cat/gideon to have value 11, got %v", value)
+		}
+	}
+	if loads["/cat/gideon"] != 1 {
+		t.Errorf("expected key /cat/gideon to be loaded once, got %d loads", loads["/cat/gideon"])
+	}
+
+	// loader misses are not cached
+	for i := 0; i < 2; i++ {
+		if value, ok := trie.Get("/missing"); ok {
+			t.Errorf("expected key /missing to be missing, found value %v", value)
+		}
+	}
+	if loads["/missing"] != 2 {
+		t.Errorf("expected key /missing to be loaded twice, got %d loads", loads["/missing"])
+	}
+
+	// loaded values failing validation are neither cached nor returned
+	tooLong := errors.New("value too long")
+	validator := func(key string, value int) error {
+		if value > 10 {
+			return tooLong
+		}
+		return nil
+	}
+	trie = NewPathTrie(WithLoader(loader), WithValidator(validator), WithDefault(-1))
+	for i := 0; i < 2; i++ {
+		if value, ok := trie.Get("/dog/marlowe"); ok || value != -1 {
+			t.Errorf("expected key /dog/marlowe to miss with default -1, got (%d, %t)", value, ok)
+		}
+	}
+	if loads["/dog/marlowe"] != 2 {
+		t.Errorf("expected key /dog/marlowe to be loaded twice, got %d loads", loads["/dog/marlowe"])
+	}
+	if value, ok := trie.GetPath([]string{"/dog", "/marlowe"}); ok {
+		t.Errorf("expected key /dog/marlowe to be missing, found value %v", value)
+	}
+}
+
+func testTriePrefixSeq(t *testing.T, trie CommonTrie[any]) {
+	table := map[string]any{
+		"":                 -1,
+		"fish":             0,
+		"/cat":             1,
+		"/notes":           30,
+		"/notes/new":       31,
+		"/notes/new/noise": 32,
+		"/notes/:id":       33,
+	}
+	for key, value := range table {
+		trie.Put(key, value)
+	}
+
+	cases := []struct {
+		prefix string
+		keys   []string
+	}{
+		{"/notes", []string{"/notes", "/notes/new", "/notes/new/noise", "/notes/:id"}},
+		{"/notes/new", []string{"/notes/new", "/notes/new/noise"}},
+		{"/cat", []string{"/cat"}},
+		{"/missing", nil},
+		{"", []string{"", "fish", "/cat", "/notes", "/notes/new", "/notes/new/noise", "/notes/:id"}},
+	}
+	for _, c := range cases {
+		var keys []string
+		for key, value := range trie.PrefixSeq(c.prefix) {
+			if value != table[key] {
+				t.Errorf("expected key %s to have value %v, got %v", key, table[key], value)
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		sort.Strings(c.keys)
+		if !reflect.DeepEqual(keys, c.keys) {
+			t.Errorf("expected prefix %s to iterate keys %v, got %v", c.prefix, c.keys, keys)
+		}
+	}
+
+	// break after the first match
+	var count int
+	for range trie.PrefixSeq("/notes") {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("expected 1 key/value before break, got %d", count)
+	}
+}
+
+func testTrieLoadLines(t *testing.T, trie CommonTrie[any]) {
+	longValue := strings.Repeat("x", 1<<20)
+	input := "# comment\n" +
+		"/cat=gideon\n" +
+		"\n" +
+		"/dog=rex\r\n" +
+		"not a key value line\n" +
+		"/long=" + longValue + "\n" +
+		"/fish=nemo" // no trailing newline
+	parse := func(line string) (string, any, bool) {
+		if strings.HasPrefix(line, "#") {
+			return "", nil, false
+		}
+		key, value, ok := strings.Cut(line, "=")
+		return key, value, ok
+	}
+	count, err := trie.LoadLines(strings.NewReader(input), parse)
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if count != 4 {
+		t.Errorf("expected 4 entries loaded, got %d", count)
+	}
+	table := map[string]any{
+		"/cat":  "gideon",
+		"/dog":  "rex",
+		"/long": longValue,
+		"/fish": "nemo",
+	}
+	for key, value := range table {
+		if v, ok := trie.Get(key); !ok || v != value {
+			t.Errorf("expected key %s to be loaded", key)
+		}
+	}
+
+	readError := errors.New("read error")
+	reader := io.MultiReader(strings.NewReader("/notes=new\n"), iotest.ErrReader(readError))
+	count, err = trie.LoadLines(reader, parse)
+	if err != readError {
+		t.Errorf("expected read error, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 entry loaded before error, got %d", count)
+	}
+}
+
+func testTrieFind(t *testing.T, trie CommonTrie[any]) {
+	table := map[string]any{
+		"":           -1,
+		"/cat":       1,
+		"/cat/kitty": 2,
+		"/dog":       3,
+		"/notes":     42,
+	}
+	for key, value := range table {
+		trie.Put(key, value)
+	}
+
+	var calls int
+	key, value, ok := trie.Find(func(key string, value any) bool {
+		calls++
+		return value == 42
+	})
+	if !ok || key != "/notes" || value != 42 {
+		t.Errorf("expected to find key /notes with value 42, got %s %v %t", key, value, ok)
+	}
+	if calls > len(table) {
+		t.Errorf("expected at most %d predicate calls, got %d", len(table), calls)
+	}
+
+	calls = 0
+	key, value, ok = trie.Find(func(key string, value any) bool {
+		calls++
+		return false
+	})
+	if ok || key != "" || value != nil {
+		t.Errorf("expected no match, got %s %v %t", key, value, ok)
+	}
+	if calls != len(table) {
+		t.Errorf("expected %d predicate calls, got %d", len(table), calls)
+	}
+}
+
+func testTrieTouch(t *testing.T, trie CommonTrie[any]) {
+	trie.Put("/a", 0)
+	trie.Touch("/a/b")
+	trie.Touch("")
+
+	// touched keys remain absent
+	for _, key := range []string{"", "/a/", "/a/b"} {
+		if value, ok := trie.Get(key); ok {
+			t.Errorf("expected touched key %s to be missing, found value %v", key, value)
+		}
+	}
+	if value, ok := trie.Get("/a"); !ok || value != 0 {
+		t.Errorf("expected key /a to have value 0, got %v", value)
+	}
+	var walked int
+	trie.Walk(func(key string, value any) error {
+		walked++
+		return nil
+	})
+	if walked != 1 {
+		t.Errorf("expected 1 key walked, got %d", walked)
+	}
+}
+
+// testTrieWalkRange tests WalkRange. If the trie normalizes keys, denormalize
+// returns a key which normalizes to the given key, which is used for keys
+// and bounds too.
+func testTrieWalkRange(t *testing.T, trie CommonTrie[any], denormalize func(key string) string) {
+	keys := []string{"", "/a", "/a/b", "/a/c", "/b", "/b/a", "/b/a/z", "/c"}
+	for i, key := range keys {
+		if denormalize != nil {
+			key = denormalize(key)
+		}
+		trie.Put(key, i)
+	}
+	cases := []struct {
+		lo, hi string
+		keys   []string
+	}{
+		{"", "/z", keys},
+		{"/a/b", "/b/a", []string{"/a/b", "/a/c", "/b"}},
+		{"/a", "/b", []string{"/a", "/a/b", "/a/c"}},
+		{"/a/a", "/a/z", []string{"/a/b", "/a/c"}},
+		{"/b/a", "/c", []string{"/b/a", "/b/a/z"}},
+		{"/a", "/a", nil},
+		{"/c", "/a", nil},
+		{"/d", "/z", nil},
+		{"", "", nil},
+	}
+	for _, c := range cases {
+		bounds := [][2]string{{c.lo, c.hi}}
+		if denormalize != nil {
+			bounds = append(bounds, [2]string{denormalize(c.lo), denormalize(c.hi)})
+		}
+		for _, b := range bounds {
+			var walked []string
+			err := trie.WalkRange(b[0], b[1], func(key string, value any) error {
+				walked = append(walked, key)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("expected error nil, got %v", err)
+			}
+			if !reflect.DeepEqual(walked, c.keys) {
+				t.Errorf("expected range [%q, %q) to walk %v, got %v", b[0], b[1], c.keys, walked)
+			}
+		}
+	}
+
+	walkerError := errors.New("walker error")
+	var walked int
+	err := trie.WalkRange("", "/z", func(key string, value any) error {
+		walked++
+		if key == "/a/b" {
+			return walkerError
+		}
+		return nil
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+	if walked != 3 {
+		t.Errorf("expected 3 keys walked before error, got %d", walked)
+	}
+
+	// walkers may delete siblings which have not been visited yet
+	var visited []string
+	err = trie.WalkRange("/a", "/z", func(key string, value any) error {
+		visited = append(visited, key)
+		if key == "/a" {
+			trie.Delete("/c")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if expected := []string{"/a", "/a/b", "/a/c", "/b", "/b/a", "/b/a/z"}; !reflect.DeepEqual(visited, expected) {
+		t.Errorf("expected walk to skip deleted key, walked %v", visited)
+	}
+}
+
+func testTrieWalkMutate(t *testing.T, trie CommonTrie[int]) {
+	table := map[string]int{
+		"":           -1,
+		"fish":       0,
+		"/cat":       1,
+		"/notes":     30,
+		"/notes/new": 31,
+	}
+	for key, value := range table {
+		trie.Put(key, value)
+	}
+
+	// increment every value in place
+	err := trie.WalkMutate(func(key string, value *int) error {
+		*value++
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	for key, value := range table {
+		if v, ok := trie.Get(key); !ok || v != value+1 {
+			t.Errorf("expected key %s to have value %d, got %d", key, value+1, v)
+		}
+	}
+
+	walkerError := errors.New("walker error")
+	var walked int
+	err = trie.WalkMutate(func(key string, value *int) error {
+		walked++
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+	if walked != 1 {
+		t.Errorf("expected 1 value walked before error, got %d", walked)
+	}
+}
+
+func TestPathTrieDeletePrefixWithKeyNormalizer(t *testing.T) {
+	trie := NewPathTrie(WithKeyNormalizer[int](strings.ToLower))
+	for i, key := range []string{"/A", "/A/B", "/C"} {
+		trie.Put(key, i)
+	}
+	if count, keys := trie.DryRunDeletePrefix("/A"); count != 2 || !reflect.DeepEqual(keys, []string{"/a", "/a/b"}) {
+		t.Errorf("expected dry run of /A to report (2, [/a /a/b]), got (%d, %v)", count, keys)
+	}
+	if deleted := trie.DeletePrefix("/A"); deleted != 2 {
+		t.Errorf("expected prefix /A to delete 2 keys, got %d", deleted)
+	}
+	if _, ok := trie.Get("/A"); ok {
+		t.Error("expected key /A to be deleted")
+	}
+	if _, ok := trie.Get("/C"); !ok {
+		t.Error("expected key /C to be kept")
+	}
+}
+
+func testTrieDeletePrefix(t *testing.T, newTrie func() CommonTrie[any], cases map[string][]string) {
+	keys := []string{"", "/cat", "/config", "/config/a", "/config/a/b", "/config/c"}
+	for prefix, expected := range cases {
+		trie := newTrie()
+		for _, key := range keys {
+			trie.Put(key, key)
+		}
+		count, dryRunKeys := trie.DryRunDeletePrefix(prefix)
+		if count != len(expected) || !reflect.DeepEqual(dryRunKeys, expected) {
+			t.Errorf("expected dry run of prefix %q to report (%d, %v), got (%d, %v)", prefix, len(expected), expected, count, dryRunKeys)
+		}
+		// the dry run leaves the trie unchanged
+		for _, key := range keys {
+			if value, ok := trie.Get(key); !ok || value != key {
+				t.Errorf("expected dry run of prefix %q to keep %s, got (%v, %t)", prefix, key, value, ok)
+			}
+		}
+
+		if deleted := trie.DeletePrefix(prefix); deleted != count {
+			t.Errorf("expected prefix %q to delete %d keys, got %d", prefix, count, deleted)
+		}
+		for _, key := range keys {
+			_, ok := trie.Get(key)
+			if wantDeleted := slices.Contains(expected, key); ok == wantDeleted {
+				t.Errorf("expected deleting prefix %q to delete %s: %t", prefix, key, wantDeleted)
+			}
+		}
+		if count, keys := trie.DryRunDeletePrefix(prefix); count != 0 || keys != nil {
+			t.Errorf("expected nothing left under prefix %q, got (%d, %v)", prefix, count, keys)
+		}
+	}
+}
+
+func testTrieReplaceSubtree(t *testing.T, trie CommonTrie[any], replacement CommonTrie[any]) {
+	for _, key := range []string{"/cat", "/config", "/config/a", "/config/a/b", "/config/c"} {
+		trie.Put(key, "old")
+	}
+	for _, key := range []string{"", "/x", "/y", "/y/z"} {
+		replacement.Put(key, "new")
+	}
+
+	if delta := trie.ReplaceSubtree("/config", replacement); delta != 0 {
+		t.Errorf("expected net change 0, got %d", delta)
+	}
+	expected := map[string]any{
+		"/cat":        "old",
+		"/config":     "new",
+		"/config/x":   "new",
+		"/config/y":   "new",
+		"/config/y/z": "new",
+	}
+	walked := make(map[string]any)
+	trie.Walk(func(key string, value any) error {
+		walked[key] = value
+		return nil
+	})
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected trie %v, got %v", expected, walked)
+	}
+
+	// replacing with an empty trie removes the subtree
+	if delta := trie.ReplaceSubtree("/config/y", NewPathTrie[any]()); delta != -2 {
+		t.Errorf("expected net change -2, got %d", delta)
+	}
+	// replacing a missing prefix adds keys
+	if delta := trie.ReplaceSubtree("/dog", replacement); delta != 4 {
+		t.Errorf("expected net change 4, got %d", delta)
+	}
+	if value, ok := trie.Get("/dog/y/z"); !ok || value != "new" {
+		t.Errorf("expected key /dog/y/z to have value new, got %v", value)
+	}
+	if value, ok := trie.Get("/config/y/z"); ok {
+		t.Errorf("expected key /config/y/z to be replaced, got %v", value)
+	}
+}
+
+type deepestKeyCase struct {
+	keys    []string
+	deepest []string // any may be returned if keys tie for depth
+	depth   int
+}
+
+func testTrieDeepestKey(t *testing.T, newTrie func() CommonTrie[any], cases []deepestKeyCase) {
+	for _, c := range cases {
+		trie := newTrie()
+		for _, key := range c.keys {
+			trie.Put(key, key)
+		}
+		key, depth := trie.DeepestKey()
+		if depth != c.depth {
+			t.Errorf("expected keys %v to have max depth %d, got %d", c.keys, c.depth, depth)
+		}
+		var found bool
+		for _, deepest := range c.deepest {
+			found = found || key == deepest
+		}
+		if !found {
+			t.Errorf("expected keys %v to have deepest key in %q, got %q", c.keys, c.deepest, key)
+		}
+	}
+}
+
+type commonPrefixCase struct {
+	keys   []string
+	prefix string
+}
+
+func testTrieCommonPrefix(t *testing.T, newTrie func() CommonTrie[any], cases []commonPrefixCase) {
+	for _, c := range cases {
+		trie := newTrie()
+		for _, key := range c.keys {
+			trie.Put(key, key)
+		}
+		if prefix := trie.CommonPrefix(); prefix != c.prefix {
+			t.Errorf("expected keys %v to have common prefix %q, got %q", c.keys, c.prefix, prefix)
+		}
+	}
+}
+
+func testTrie(t *testing.T, trie Trie[any]) {
+	const firstPutValue = "first put"
+	cases := []struct {
+		key   string
+		value any
+	}{
+		{"fish", 0},
+		{"/cat", 1},
+		{"/dog", 2},
+		{"/cats", 3},
+		{"/caterpillar", 4},
+		{"/cat/gideon", 5},
+		{"/cat/giddy", 6},
+		{"مسار", 7},
+		{"這是第三個值", 8},
+	}
+
+	// get missing keys
+	for _, c := range cases {
+		if value, ok := trie.Get(c.key); ok {
+			t.Errorf("expected key %s to be missing, found value %v", c.key, value)
+		}
+	}
+
+	// initial put
+	for _, c := range cases {
+		if isNew := trie.Put(c.key, firstPutValue); !isNew {
+			t.Errorf("expected key %s to be missing", c.key)
+		}
+	}
+
+	// subsequent put
+	for _, c := range cases {
+		if isNew := trie.Put(c.key, c.value); isNew {
+			t.Errorf("expected key %s to have a value already", c.key)
+		}
+	}
+
+	// get
+	for _, c := range cases {
+		if value, ok := trie.Get(c.key); !ok || value != c.value {
+			t.Errorf("expected key %s to have value %v, got %v", c.key, c.value, value)
+		}
+	}
+
+	// delete, expect Delete to return true indicating a node was nil'd
+	for _, c := range cases {
+		if deleted := trie.Delete(c.key); !deleted {
+			t.Errorf("expected key %s to be deleted", c.key)
+		}
+	}
+
+	// delete cleaned all the way to the first character
+	// expect Delete to return false bc no node existed to nil
+	for _, c := range cases {
+		if deleted := trie.Delete(string(c.key[0])); deleted {
+			t.Errorf("expected key %s to be cleaned by delete", string(c.key[0]))
+		}
+	}
+
+	// get deleted keys
+	for _, c := range cases {
+		if value, ok := trie.Get(c.key); ok {
+			t.Errorf("expected key %s to be deleted, got value %v", c.key, value)
+		}
+	}
+}
+
+func testNilBehavior(t *testing.T, trie Trie[any]) {
+	cases := []struct {
+		key   string
+		value any
+	}{
+		{"/cat", 1},
+		{"/catamaran", 2},
+		{"/caterpillar", nil},
+	}
+	expectNilValues := []string{"/caterpillar"}
+	expectNotPresentValues := []string{"/", "/c", "/ca", "/other"}
+
+	// initial put
+	for _, c := range cases {
+		if isNew := trie.Put(c.key, c.value); !isNew {
+			t.Errorf("expected key %s to be missing", c.key)
+		}
+	}
+
+	// get nil
+	for _, key := range expectNilValues {
+		if _, ok := trie.Get(key); !ok {
+			t.Errorf("expected key %s to have value nil, but was not present", key)
+		}
+	}
+
+	// get not inserted values
+	for _, key := range expectNotPresentValues {
+		if value, ok := trie.Get(key); ok {
+			t.Errorf("expected key %s to not be present, but was present with value %v", key, value)
+		}
+	}
+}
+
+func testTrieRoot(t *testing.T, trie Trie[any]) {
+	const firstPutValue = "first put"
+	const putValue = "value"
+
+	if value, ok := trie.Get(""); ok {
+		t.Errorf("expected key '' to be missing, found value %v", value)
+	}
+	if !trie.Put("", firstPutValue) {
+		t.Error("expected key '' to be missing")
+	}
+	if trie.Put("", putValue) {
+		t.Error("expected key '' to have a value already")
+	}
+	if value, ok := trie.Get(""); !ok || value != putValue {
+		t.Errorf("expected key '' to have value %v, got %v", putValue, value)
+	}
+	if !trie.Delete("") {
+		t.Error("expected key '' to be deleted")
+	}
+	if value, ok := trie.Get(""); ok {
+		t.Errorf("expected key '' to be deleted, got value %v", value)
+	}
+}
+
+func testTrieWalk(t *testing.T, trie Trie[any]) {
+	table := map[string]any{
+		"":             -1,
+		"fish":         0,
+		"/cat":         1,
+		"/dog":         2,
+		"/cats":        3,
+		"/caterpillar": 4,
+		"/notes":       30,
+		"/notes/new":   31,
+		"/notes/:id":   32,
+	}
+	// key -> times walked
+	walked := make(map[string]int)
+	for key := range table {
+		walked[key] = 0
+	}
+
+	for key, value := range table {
+		if isNew := trie.Put(key, value); !isNew {
+			t.Errorf("expected key %s to be missing", key)
+		}
+	}
+
+	walker := func(key string, value any) error {
+		// value for each walked key is correct
+		if value != table[key] {
+			t.Errorf("expected key %s to have value %v, got %v", key, table[key], value)
+		}
+		walked[key]++
+		return nil
+	}
+	if err := trie.Walk(walker); err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+
+	// each key/value walked exactly once
+	for key, walkedCount := range walked {
+		if walkedCount != 1 {
+			t.Errorf("expected key %s to be walked exactly once, got %v", key, walkedCount)
+		}
+	}
+}
+
+func testTrieWalkError(t *testing.T, trie Trie[any]) {
+	table := map[string]any{
+		"/L1/L2A":        1,
+		"/L1/L2B/L3A":    2,
+		"/L1/L2B/L3B/L4": 42,
+		"/L1/L2B/L3C":    4,
+		"/L1/L2C":        5,
+	}
+
+	walkerError := errors.New("walker error")
+	walked := 0
+
+	for key, value := range table {
+		trie.Put(key, value)
+	}
+	walker := func(key string, value interface{}) error {
+		if value == 42 {
+			return walkerError
+		}
+		walked++
+		return nil
+	}
+	if err := trie.Walk(walker); err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+	if len(table) == walked {
+		t.Errorf("expected nodes walked < %d, got %d", len(table), walked)
+	}
+}
+
+func testTrieWalkReentrant(t *testing.T, trie Trie[any]) {
+	table := map[string]any{
+		"/cat":   1,
+		"/dog":   2,
+		"/notes": 3,
+		"fish":   4,
+	}
+	for key, value := range table {
+		trie.Put(key, value)
+	}
+
+	// walker puts new keys as it walks
+	walked := make(map[string]int)
+	err := trie.Walk(func(key string, value any) error {
+		walked[key]++
+		trie.Put(key+"/new", value)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	for key := range table {
+		if walked[key] != 1 {
+			t.Errorf("expected key %s to be walked exactly once, got %d", key, walked[key])
+		}
+		// new keys under walked nodes are not walked
+		if walked[key+"/new"] != 0 {
+			t.Errorf("expected key %s/new to not be walked, got %d", key, walked[key+"/new"])
+		}
+		if value, ok := trie.Get(key + "/new"); !ok || value != table[key] {
+			t.Errorf("expected key %s/new to have value %v, got %v", key, table[key], value)
+		}
+	}
+}
+
+func testTrieWalkPostOrder(t *testing.T, trie CommonTrie[any]) {
+	keys := []string{"", "/a", "/a/b", "/a/b/c", "/a/d", "/e", "/e/f"}
+	for _, key := range keys {
+		trie.Put(key, key)
+	}
+
+	// key -> position walked
+	walked := make(map[string]int)
+	err := trie.WalkPostOrder(func(key string, value any) error {
+		if value != key {
+			t.Errorf("expected key %s to have value %s, got %v", key, key, value)
+		}
+		walked[key] = len(walked)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if len(walked) != len(keys) {
+		t.Errorf("expected %d keys walked, got %d", len(keys), len(walked))
+	}
+	// descendants are walked before their ancestors
+	for _, ancestor := range keys {
+		for _, key := range keys {
+			if key != ancestor && strings.HasPrefix(key, ancestor) && walked[key] > walked[ancestor] {
+				t.Errorf("expected key %s to be walked before %s", key, ancestor)
+			}
+		}
+	}
+
+	walkerError := errors.New("walker error")
+	err = trie.WalkPostOrder(func(key string, value any) error {
+		if key == "/a/b" {
+			return walkerError
+		}
+		return nil
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+}
+
+type parentCase struct {
+	key       string
+	parentKey string
+	value     any
+	ok        bool
+}
+
+func testTrieParent(t *testing.T, trie CommonTrie[any]) {
+	trie.Put("/a", 1)
+	trie.Put("/a/b/c", 2)
+
+	cases := []parentCase{
+		{"", "", nil, false},
+		{"/a", "", nil, false},
+		{"/x", "", nil, false},
+		// immediate parent /a/b has no value
+		{"/a/b/c", "/a", 1, true},
+		{"/a/b", "/a", 1, true},
+		{"/a/b/c/d", "/a/b/c", 2, true},
+		{"/a/b/x/y", "/a", 1, true},
+	}
+	check := func() {
+		t.Helper()
+		for _, c := range cases {
+			parentKey, value, ok := trie.Parent(c.key)
+			if parentKey != c.parentKey || value != c.value || ok != c.ok {
+				t.Errorf("expected key %s to have parent (%q, %v, %t), got (%q, %v, %t)", c.key, c.parentKey, c.value, c.ok, parentKey, value, ok)
+			}
+		}
+	}
+	check()
+
+	// root is the parent of top level keys
+	trie.Put("", 0)
+	cases[1] = parentCase{"/a", "", 0, true}
+	cases[2] = parentCase{"/x", "", 0, true}
+	check()
+}
+
+type groupedWalk struct {
+	parentKey string
+	children  []KeyValue[any]
+}
+
+func testTrieWalkGrouped(t *testing.T, trie CommonTrie[any], expected []groupedWalk) {
+	var walked []groupedWalk
+	err := trie.WalkGrouped(func(parentKey string, children []KeyValue[any]) error {
+		walked = append(walked, groupedWalk{parentKey, children})
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected groups %v, got %v", expected, walked)
+	}
+
+	walkerError := errors.New("walker error")
+	var calls int
+	err = trie.WalkGrouped(func(parentKey string, children []KeyValue[any]) error {
+		calls++
+		return walkerError
+	})
+	if err != walkerError || calls != 1 {
+		t.Errorf("expected walk aborted after 1 call with walker error, got %d calls and %v", calls, err)
+	}
+}
+
+func testTriePutRef(t *testing.T, trie CommonTrie[[]int]) {
+	// Put copies the value
+	value := []int{1, 2}
+	trie.Put("/copied", value)
+	trie.WalkMutate(func(key string, value *[]int) error {
+		*value = append(*value, 3)
+		return nil
+	})
+	if !reflect.DeepEqual(value, []int{1, 2}) {
+		t.Errorf("expected value [1 2] to be unchanged, got %v", value)
+	}
+	if stored, _ := trie.Get("/copied"); !reflect.DeepEqual(stored, []int{1, 2, 3}) {
+		t.Errorf("expected key /copied to have value [1 2 3], got %v", stored)
+	}
+
+	// PutRef shares the value
+	shared := []int{1, 2}
+	if isNew := trie.PutRef("/shared", &shared); !isNew {
+		t.Error("expected key /shared to be missing")
+	}
+	shared = append(shared, 3)
+	if stored, _ := trie.Get("/shared"); !reflect.DeepEqual(stored, []int{1, 2, 3}) {
+		t.Errorf("expected key /shared to have value [1 2 3], got %v", stored)
+	}
+	trie.WalkMutate(func(key string, value *[]int) error {
+		*value = append(*value, 4)
+		return nil
+	})
+	if !reflect.DeepEqual(shared, []int{1, 2, 3, 4}) {
+		t.Errorf("expected shared value [1 2 3 4], got %v", shared)
+	}
+
+	if isNew := trie.PutRef("/shared", &[]int{5}); isNew {
+		t.Error("expected key /shared to be replaced")
+	}
+	if trie.PutRef("/nil", nil) {
+		t.Error("expected nil value to be ignored")
+	}
+	if _, ok := trie.Get("/nil"); ok {
+		t.Error("expected key /nil to be missing")
+	}
+}
+
+func testTrieWalkWithMetrics(t *testing.T, trie CommonTrie[any], expected WalkMetrics) {
+	walked := make(map[string]bool)
+	metrics, err := trie.WalkWithMetrics(func(key string, value any) error {
+		walked[key] = true
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if metrics != expected {
+		t.Errorf("expected metrics %+v, got %+v", expected, metrics)
+	}
+	if len(walked) != expected.ValuesDelivered {
+		t.Errorf("expected %d keys walked, got %d", expected.ValuesDelivered, len(walked))
+	}
+
+	walkerError := errors.New("walker error")
+	metrics, err = trie.WalkWithMetrics(func(key string, value any) error {
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+	if metrics.ValuesDelivered != 1 {
+		t.Errorf("expected 1 value delivered before error, got %d", metrics.ValuesDelivered)
+	}
+
+	metrics, _ = NewPathTrie[any]().WalkWithMetrics(nil)
+	if expected := (WalkMetrics{NodesVisited: 1}); metrics != expected {
+		t.Errorf("expected empty trie metrics %+v, got %+v", expected, metrics)
+	}
+}
+
+func testTrieIsEmpty(t *testing.T, trie CommonTrie[any]) {
+	if !trie.IsEmpty() {
+		t.Error("expected new trie to be empty")
+	}
+	// internal nodes hold no values
+	trie.Touch("/cat/gideon")
+	if !trie.IsEmpty() {
+		t.Error("expected trie with only internal nodes to be empty")
+	}
+	trie.Put("/cat/gideon/paw", 1)
+	if trie.IsEmpty() {
+		t.Error("expected trie with a value to not be empty")
+	}
+	trie.Delete("/cat/gideon/paw")
+	trie.Put("", 0)
+	if trie.IsEmpty() {
+		t.Error("expected trie with a root value to not be empty")
+	}
+	trie.Delete("")
+	if !trie.IsEmpty() {
+		t.Error("expected trie to be empty after deletes")
+	}
+}
+
+func testTrieWalkLeaves(t *testing.T, trie CommonTrie[any]) {
+	for _, key := range []string{"", "/cat", "/cat/gideon", "/cat/mochi", "/dog", "/fish/nemo"} {
+		trie.Put(key, key)
+	}
+	// a node with only internal descendants is a leaf
+	trie.Touch("/dog/rex")
+
+	walked := make(map[string]bool)
+	err := trie.WalkLeaves(func(key string, value any) error {
+		if value != key {
+			t.Errorf("expected key %s to have value %s, got %v", key, key, value)
+		}
+		walked[key] = true
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	expected := map[string]bool{"/cat/gideon": true, "/cat/mochi": true, "/dog": true, "/fish/nemo": true}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected leaves %v, got %v", expected, walked)
+	}
+
+	walkerError := errors.New("walker error")
+	err = trie.WalkLeaves(func(key string, value any) error {
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+}
+
+// testTrieWalkBetweenPrefixes tests WalkBetweenPrefixes. If the trie
+// normalizes keys, denormalize returns a key which normalizes to the given
+// key, which is used for keys and prefixes too.
+func testTrieWalkBetweenPrefixes(t *testing.T, trie CommonTrie[any], denormalize func(key string) string) {
+	keys := []string{"", "/a", "/a/b", "/a/c", "/b", "/b/a", "/b/a/z", "/c", "/c/d"}
+	for i, key := range keys {
+		if denormalize != nil {
+			key = denormalize(key)
+		}
+		trie.Put(key, i)
+	}
+	cases := []struct {
+		from, to string
+		keys     []string
+	}{
+		{"", "/z", keys},
+		// keys under the to prefix are included
+		{"/a/b", "/b", []string{"/a/b", "/a/c", "/b", "/b/a", "/b/a/z"}},
+		{"/a", "/a", []string{"/a", "/a/b", "/a/c"}},
+		{"/a/c", "/b/a", []string{"/a/c", "/b", "/b/a", "/b/a/z"}},
+		// keys before the from prefix are excluded
+		{"/b/a", "/c", []string{"/b/a", "/b/a/z", "/c", "/c/d"}},
+		{"/c", "/a", nil},
+		{"/d", "/z", nil},
+		{"", "", keys}, // every key is under the empty prefix
+	}
+	for _, c := range cases {
+		prefixes := [][2]string{{c.from, c.to}}
+		if denormalize != nil {
+			prefixes = append(prefixes, [2]string{denormalize(c.from), denormalize(c.to)})
+		}
+		for _, p := range prefixes {
+			var walked []string
+			err := trie.WalkBetweenPrefixes(p[0], p[1], func(key string, value any) error {
+				walked = append(walked, key)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("expected error nil, got %v", err)
+			}
+			if !reflect.DeepEqual(walked, c.keys) {
+				t.Errorf("expected prefixes [%q, %q] to walk %v, got %v", p[0], p[1], c.keys, walked)
+			}
+		}
+	}
+
+	walkerError := errors.New("walker error")
+	err := trie.WalkBetweenPrefixes("", "/z", func(key string, value any) error {
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
+	}
+
+	// walkers may delete siblings which have not been visited yet
+	var visited []string
+	err = trie.WalkBetweenPrefixes("/a", "/c", func(key string, value any) error {
+		visited = append(visited, key)
+		if key == "/a" {
+			trie.Delete("/c/d")
+			trie.Delete("/c")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if expected := []string{"/a", "/a/b", "/a/c", "/b", "/b/a", "/b/a/z"}; !reflect.DeepEqual(visited, expected) {
+		t.Errorf("expected walk to skip deleted keys, walked %v", visited)
+	}
+}
+
+func testTrieNil(t *testing.T, trie CommonTrie[any]) {
+	if value, ok := trie.Get("/cat"); ok || value != nil {
+		t.Errorf("expected nil trie Get to miss, got %v", value)
+	}
+	walker := func(key string, value any) error {
+		t.Errorf("expected nil trie walk to not call walker, got key %s", key)
+		return nil
+	}
+	if err := trie.Walk(walker); err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if err := trie.WalkPath("/cat", walker); err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if !trie.IsEmpty() {
+		t.Error("expected nil trie to be empty")
+	}
+	if count := trie.TopLevelCount(); count != 0 {
+		t.Errorf("expected nil trie to have 0 top level count, got %d", count)
+	}
+}
+
+func testTrieWalkDelete(t *testing.T, trie CommonTrie[int]) {
+	keys := []string{"", "/a", "/a/b", "/a/b/c", "/a/d", "/e", "/e/f", "/g/h/i", "/g/h/j"}
+	for i, key := range keys {
+		trie.Put(key, i)
+	}
+
+	// delete every other key
+	err := trie.WalkDelete(func(key string, value int) (bool, error) {
+		return value%2 == 0, nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	for i, key := range keys {
+		value, ok := trie.Get(key)
+		if deleted := i%2 == 0; ok == deleted || (ok && value != i) {
+			t.Errorf("expected key %s deleted %t, got (%d, %t)", key, deleted, value, ok)
+		}
+	}
+	walked := make(map[string]int)
+	trie.Walk(func(key string, value int) error {
+		walked[key] = value
+		return nil
+	})
+	if expected := map[string]int{"/a": 1, "/a/b/c": 3, "/e": 5, "/g/h/i": 7}; !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected remaining keys %v, got %v", expected, walked)
+	}
+
+	// an error aborts the walk after deleting the current key
+	walkerError := errors.New("walker error")
+	var calls int
+	err = trie.WalkDelete(func(key string, value int) (bool, error) {
+		calls++
+		return true, walkerError
+	})
+	if err != walkerError || calls != 1 {
+		t.Errorf("expected walk aborted after 1 call with walker error, got %d calls and %v", calls, err)
+	}
+
+	// deleting the remaining keys removes all nodes
+	trie.WalkDelete(func(key string, value int) (bool, error) {
+		return true, nil
+	})
+	if !trie.IsEmpty() {
+		t.Error("expected trie to be empty")
+	}
+	if count := trie.TopLevelCount(); count != 0 {
+		t.Errorf("expected all nodes to be removed, got %d top level nodes", count)
+	}
+	trie.Put("/a/b", 10)
+	if value, ok := trie.Get("/a/b"); !ok || value != 10 {
+		t.Errorf("expected key /a/b to have value 10, got %d", value)
+	}
+}
+
+func testTrieWalkDeadline(t *testing.T, trie CommonTrie[any]) {
+	const numKeys = 4 * deadlineCheckInterval
+	for i := 0; i < numKeys; i++ {
+		trie.Put(fmt.Sprintf("/%d/%d", i%10, i), i)
+	}
+
+	// a past deadline aborts before walking
+	err := trie.WalkDeadline(time.Now().Add(-time.Second), func(key string, value any) error {
+		t.Errorf("expected walker to not be called, got key %s", key)
+		return nil
+	})
+	if err != ErrWalkDeadline {
+		t.Errorf("expected ErrWalkDeadline, got %v", err)
+	}
+
+	// a deadline which passes mid-walk aborts promptly
+	var walked int
+	deadline := time.Now().Add(10 * time.Millisecond)
+	err = trie.WalkDeadline(deadline, func(key string, value any) error {
+		if walked == 0 {
+			time.Sleep(time.Until(deadline))
+		}
+		walked++
+		return nil
+	})
+	if err != ErrWalkDeadline {
+		t.Errorf("expected ErrWalkDeadline, got %v", err)
+	}
+	if walked >= deadlineCheckInterval {
+		t.Errorf("expected walk to abort within %d values, walked %d", deadlineCheckInterval, walked)
+	}
 
-func TestPathTrieWalk(t *testing.T) {
-	trie := NewPathTrie[any]()
-	testTrieWalk(t, trie)
+	// a future deadline walks every value
+	walked = 0
+	err = trie.WalkDeadline(time.Now().Add(time.Hour), func(key string, value any) error {
+		walked++
+		return nil
+	})
+	if err != nil || walked != numKeys {
+		t.Errorf("expected %d values walked with error nil, got %d and %v", numKeys, walked, err)
+	}
 }
 
-func TestPathTrieWalkError(t *testing.T) {
-	trie := NewPathTrie[any]()
-	testTrieWalkError(t, trie)
-}
+func testTrieWalkCollectErrors(t *testing.T, trie CommonTrie[any]) {
+	if errs := trie.WalkCollectErrors(func(key string, value any) error {
+		return errors.New(key)
+	}); errs != nil {
+		t.Errorf("expected no errors walking an empty trie, got %v", errs)
+	}
 
-func TestPathTrieWalkPath(t *testing.T) {
-	trie := NewPathTrie[any]()
-	testTrieWalkPath(t, trie)
-}
+	keys := []string{"", "/a", "/a/b", "/c", "/d/e"}
+	for _, key := range keys {
+		trie.Put(key, strings.Count(key, "/"))
+	}
+	// every key two segments deep fails
+	var walked int
+	errs := trie.WalkCollectErrors(func(key string, value any) error {
+		walked++
+		if value == 2 {
+			return fmt.Errorf("invalid key %q", key)
+		}
+		return nil
+	})
+	if walked != len(keys) {
+		t.Errorf("expected walk to visit %d keys, got %d", len(keys), walked)
+	}
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	sort.Strings(messages)
+	expected := []string{`invalid key "/a/b"`, `invalid key "/d/e"`}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected errors %v, got %v", expected, messages)
+	}
 
-func TestPathTrieWalkPathError(t *testing.T) {
-	trie := NewPathTrie[any]()
-	testTrieWalkPathError(t, trie)
+	if errs := trie.WalkCollectErrors(func(key string, value any) error {
+		return nil
+	}); errs != nil {
+		t.Errorf("expected no errors, got %v", errs)
+	}
 }
 
-func testTrie(t *testing.T, trie Trie[any]) {
-	const firstPutValue = "first put"
-	cases := []struct {
-		key   string
-		value any
-	}{
-		{"fish", 0},
-		{"/cat", 1},
-		{"/dog", 2},
-		{"/cats", 3},
-		{"/caterpillar", 4},
-		{"/cat/gideon", 5},
-		{"/cat/giddy", 6},
-		{"مسار", 7},
-		{"這是第三個值", 8},
+func testTrieWalkLimit(t *testing.T, trie CommonTrie[any]) {
+	keys := []string{"", "/a", "/a/b", "/c", "/d/e"}
+	for _, key := range keys {
+		trie.Put(key, key)
 	}
-
-	// get missing keys
-	for _, c := range cases {
-		if value, ok := trie.Get(c.key); ok {
-			t.Errorf("expected key %s to be missing, found value %v", c.key, value)
+	cases := map[int]int{-1: 0, 0: 0, 1: 1, 3: 3, 5: 5, 10: 5}
+	for limit, expected := range cases {
+		walked := make(map[string]bool)
+		err := trie.WalkLimit(limit, func(key string, value any) error {
+			walked[key] = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("expected error nil, got %v", err)
+		}
+		if len(walked) != expected {
+			t.Errorf("expected limit %d to walk %d keys, got %d", limit, expected, len(walked))
 		}
 	}
 
-	// initial put
-	for _, c := range cases {
-		if isNew := trie.Put(c.key, firstPutValue); !isNew {
-			t.Errorf("expected key %s to be missing", c.key)
-		}
+	walkerError := errors.New("walker error")
+	err := trie.WalkLimit(3, func(key string, value any) error {
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
 	}
+}
 
-	// subsequent put
-	for _, c := range cases {
-		if isNew := trie.Put(c.key, c.value); isNew {
-			t.Errorf("expected key %s to have a value already", c.key)
+func testTrieAncestors(t *testing.T, trie CommonTrie[any]) {
+	for _, key := range []string{"", "/docs", "/docs/guide/intro", "/docs/guide/intro/這是", "/blog"} {
+		trie.Put(key, key)
+	}
+	cases := map[string][]string{
+		"/docs/guide/intro/這是": {"", "/docs", "/docs/guide/intro", "/docs/guide/intro/這是"},
+		"/docs/guide/intro":    {"", "/docs", "/docs/guide/intro"},
+		"/docs/guide/setup":    {"", "/docs"},
+		"/blog":                {"", "/blog"},
+		"/about":               {""},
+	}
+	for key, expectedKeys := range cases {
+		var expected []KeyValue[any]
+		for _, k := range expectedKeys {
+			expected = append(expected, KeyValue[any]{Key: k, Value: k})
+		}
+		if ancestors := trie.Ancestors(key); !reflect.DeepEqual(ancestors, expected) {
+			t.Errorf("expected key %s to have ancestors %v, got %v", key, expected, ancestors)
 		}
 	}
+	trie.Delete("")
+	if ancestors := trie.Ancestors("/about"); ancestors != nil {
+		t.Errorf("expected key /about to have no ancestors, got %v", ancestors)
+	}
+}
 
-	// get
-	for _, c := range cases {
-		if value, ok := trie.Get(c.key); !ok || value != c.value {
-			t.Errorf("expected key %s to have value %v, got %v", c.key, c.value, value)
+func testTrieExplain(t *testing.T, trie CommonTrie[any], cases map[string]string) {
+	trie.Put("/a/b", 1)
+	for key, expected := range cases {
+		if explanation := trie.Explain(key); explanation != expected {
+			t.Errorf("expected key %s to be explained as %s, got %s", key, expected, explanation)
 		}
 	}
+}
 
-	// delete, expect Delete to return true indicating a node was nil'd
-	for _, c := range cases {
-		if deleted := trie.Delete(c.key); !deleted {
-			t.Errorf("expected key %s to be deleted", c.key)
+func testTrieDeleteAll(t *testing.T, newTrie func() CommonTrie[int]) {
+	keys := []string{"", "/a", "/a!", "/a/b", "/a/b/c", "/a/d", "/e", "/e/f", "/g/h/i", "/g/h/j"}
+	deleted := []string{"/g/h/j", "/a/b/c", "/a", "/missing/x", "/a!", "/z/y/x", "/a/b", "/g/h/i", "/e", "/a/b/c"}
+	batched, individual := newTrie(), newTrie()
+	for _, trie := range []CommonTrie[int]{batched, individual} {
+		for i, key := range keys {
+			trie.Put(key, i)
 		}
+		trie.Touch("/z/y")
 	}
 
-	// delete cleaned all the way to the first character
-	// expect Delete to return false bc no node existed to nil
-	for _, c := range cases {
-		if deleted := trie.Delete(string(c.key[0])); deleted {
-			t.Errorf("expected key %s to be cleaned by delete", string(c.key[0]))
-		}
+	if count := batched.DeleteAll(deleted); count != 7 {
+		t.Errorf("expected 7 keys deleted, got %d", count)
+	}
+	for _, key := range deleted {
+		individual.Delete(key)
+	}
+	walked := make(map[string]int)
+	batched.Walk(func(key string, value int) error {
+		walked[key] = value
+		return nil
+	})
+	if expected := map[string]int{"": 0, "/a/d": 5, "/e/f": 7}; !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected remaining keys %v, got %v", expected, walked)
+	}
+	// the remaining nodes match those left by individual deletes
+	if nodes, expected := nodeKeys(batched, ""), nodeKeys(individual, ""); !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("expected nodes %v, got %v", expected, nodes)
+	}
+	if count := batched.DeleteAll(nil); count != 0 {
+		t.Errorf("expected 0 keys deleted, got %d", count)
 	}
+}
 
-	// get deleted keys
-	for _, c := range cases {
-		if value, ok := trie.Get(c.key); ok {
-			t.Errorf("expected key %s to be deleted, got value %v", c.key, value)
-		}
+// nodeKeys returns the keys of the nodes under the given key in depth first
+// order, whether or not they hold values.
+func nodeKeys[T any](trie CommonTrie[T], key string) []string {
+	var keys []string
+	for _, child := range trie.ChildrenKeys(key) {
+		keys = append(keys, child)
+		keys = append(keys, nodeKeys(trie, child)...)
 	}
+	return keys
 }
 
-func testNilBehavior(t *testing.T, trie Trie[any]) {
-	cases := []struct {
-		key   string
-		value any
-	}{
-		{"/cat", 1},
-		{"/catamaran", 2},
-		{"/caterpillar", nil},
+type treeNode struct {
+	key    string
+	value  any
+	isLast bool
+	depth  int
+}
+
+func testTrieWalkTree(t *testing.T, trie CommonTrie[any], keys []string, expected []treeNode) {
+	for _, key := range keys {
+		trie.Put(key, key)
+	}
+	var walked []treeNode
+	err := trie.WalkTree(func(key string, value any, isLast bool, depth int) error {
+		walked = append(walked, treeNode{key, value, isLast, depth})
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected tree %v, got %v", expected, walked)
 	}
-	expectNilValues := []string{"/caterpillar"}
-	expectNotPresentValues := []string{"/", "/c", "/ca", "/other"}
 
-	// initial put
-	for _, c := range cases {
-		if isNew := trie.Put(c.key, c.value); !isNew {
-			t.Errorf("expected key %s to be missing", c.key)
+	// an error aborts the walk
+	walkerError := errors.New("walker error")
+	var calls int
+	err = trie.WalkTree(func(key string, value any, isLast bool, depth int) error {
+		if calls++; depth == 2 {
+			return walkerError
 		}
+		return nil
+	})
+	if err != walkerError || calls != 3 {
+		t.Errorf("expected walk aborted after 3 calls with walker error, got %d calls and %v", calls, err)
 	}
+}
 
-	// get nil
-	for _, key := range expectNilValues {
-		if _, ok := trie.Get(key); !ok {
-			t.Errorf("expected key %s to have value nil, but was not present", key)
+func testTrieSample(t *testing.T, trie CommonTrie[any]) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	if key, value, ok := trie.Sample(rng); ok {
+		t.Errorf("expected empty trie to have no sample, got (%s, %v)", key, value)
+	}
+	keys := []string{"", "/a", "/a/b/c", "/d"}
+	for _, key := range keys {
+		trie.Put(key, key)
+	}
+	const samples = 40000
+	counts := make(map[string]int)
+	for i := 0; i < samples; i++ {
+		key, value, ok := trie.Sample(rng)
+		if !ok || value != key {
+			t.Fatalf("expected a sample with its value, got (%s, %v, %t)", key, value, ok)
+		}
+		counts[key]++
+	}
+	// each key is expected samples/4 times, with a standard deviation of
+	// about 87, so allow a generous margin
+	expected := samples / len(keys)
+	for _, key := range keys {
+		if count := counts[key]; count < expected*9/10 || count > expected*11/10 {
+			t.Errorf("expected key %q to be sampled about %d times, got %d", key, expected, count)
 		}
 	}
+}
 
-	// get not inserted values
-	for _, key := range expectNotPresentValues {
-		if value, ok := trie.Get(key); ok {
-			t.Errorf("expected key %s to not be present, but was present with value %v", key, value)
+func testTrieKeysToDepth(t *testing.T, trie CommonTrie[any], keys []string, cases map[int][]string) {
+	for _, key := range keys {
+		trie.Put(key, key)
+	}
+	for depth, expected := range cases {
+		if keys := trie.KeysToDepth(depth); !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected keys to depth %d %v, got %v", depth, expected, keys)
 		}
 	}
 }
 
-func testTrieRoot(t *testing.T, trie Trie[any]) {
-	const firstPutValue = "first put"
-	const putValue = "value"
+// testTrieNearestByPrefix puts each key with itself as its value and touches
+// the touched key, whose nodes hold no values, before checking the nearest
+// key to each query.
+func testTrieNearestByPrefix(t *testing.T, trie CommonTrie[any], keys []string, touched string, cases map[string]string) {
+	if key, _, ok := trie.NearestByPrefix("a"); ok {
+		t.Errorf("expected empty trie to have no nearest key, got %s", key)
+	}
+	for _, key := range keys {
+		trie.Put(key, key)
+	}
+	trie.Touch(touched)
+	for query, expected := range cases {
+		key, value, ok := trie.NearestByPrefix(query)
+		if !ok || key != expected || value != expected {
+			t.Errorf("expected nearest key to %q to be %s, got (%s, %v, %t)", query, expected, key, value, ok)
+		}
+	}
+}
 
-	if value, ok := trie.Get(""); ok {
-		t.Errorf("expected key '' to be missing, found value %v", value)
+func testTrieMinMaxKey(t *testing.T, trie CommonTrie[any], keys, touched []string, min, max string) {
+	if key, ok := trie.MinKey(); ok {
+		t.Errorf("expected empty trie to have no min key, got %s", key)
 	}
-	if !trie.Put("", firstPutValue) {
-		t.Error("expected key '' to be missing")
+	if key, ok := trie.MaxKey(); ok {
+		t.Errorf("expected empty trie to have no max key, got %s", key)
 	}
-	if trie.Put("", putValue) {
-		t.Error("expected key '' to have a value already")
+	// nodes without values are skipped
+	for _, key := range touched {
+		trie.Touch(key)
 	}
-	if value, ok := trie.Get(""); !ok || value != putValue {
-		t.Errorf("expected key '' to have value %v, got %v", putValue, value)
+	for _, key := range keys {
+		trie.Put(key, key)
 	}
-	if !trie.Delete("") {
-		t.Error("expected key '' to be deleted")
+	if key, ok := trie.MinKey(); !ok || key != min {
+		t.Errorf("expected min key %s, got %s", min, key)
 	}
-	if value, ok := trie.Get(""); ok {
-		t.Errorf("expected key '' to be deleted, got value %v", value)
+	if key, ok := trie.MaxKey(); !ok || key != max {
+		t.Errorf("expected max key %s, got %s", max, key)
+	}
+	trie.Put("", "")
+	if key, ok := trie.MinKey(); !ok || key != "" {
+		t.Errorf("expected min key to be the root key, got %s", key)
+	}
+	for _, key := range keys {
+		trie.Delete(key)
+	}
+	if key, ok := trie.MaxKey(); !ok || key != "" {
+		t.Errorf("expected max key to be the root key, got %s", key)
 	}
 }
 
-func testTrieWalk(t *testing.T, trie Trie[any]) {
-	table := map[string]any{
-		"":             -1,
-		"fish":         0,
-		"/cat":         1,
-		"/dog":         2,
-		"/cats":        3,
-		"/caterpillar": 4,
-		"/notes":       30,
-		"/notes/new":   31,
-		"/notes/:id":   32,
+func testTrieWalkWithChildCount(t *testing.T, trie CommonTrie[any], keys []string, expected map[string]int) {
+	for _, key := range keys {
+		trie.Put(key, key)
 	}
-	// key -> times walked
 	walked := make(map[string]int)
-	for key := range table {
-		walked[key] = 0
+	err := trie.WalkWithChildCount(func(key string, value any, childCount int) error {
+		if value != key {
+			t.Errorf("expected key %s to have value %s, got %v", key, key, value)
+		}
+		walked[key] = childCount
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("expected child counts %v, got %v", expected, walked)
 	}
 
-	for key, value := range table {
-		if isNew := trie.Put(key, value); !isNew {
-			t.Errorf("expected key %s to be missing", key)
-		}
+	walkerError := errors.New("walker error")
+	err = trie.WalkWithChildCount(func(key string, value any, childCount int) error {
+		return walkerError
+	})
+	if err != walkerError {
+		t.Errorf("expected walker error, got %v", err)
 	}
+}
 
-	walker := func(key string, value any) error {
-		// value for each walked key is correct
-		if value != table[key] {
-			t.Errorf("expected key %s to have value %v, got %v", key, table[key], value)
-		}
-		walked[key]++
-		return nil
+func testTrieRekey(t *testing.T, trie CommonTrie[int]) {
+	for i, key := range []string{"", "/a", "/a/B", "/b", "/A"} {
+		trie.Put(key, i)
 	}
-	if err := trie.Walk(walker); err != nil {
-		t.Errorf("expected error nil, got %v", err)
+	collect := func(trie Trie[int]) map[string]int {
+		walked := make(map[string]int)
+		trie.Walk(func(key string, value int) error {
+			walked[key] = value
+			return nil
+		})
+		return walked
 	}
 
-	// each key/value walked exactly once
-	for key, walkedCount := range walked {
-		if walkedCount != 1 {
-			t.Errorf("expected key %s to be walked exactly once, got %v", key, walkedCount)
-		}
+	prefixed := trie.Rekey(func(key string) string { return "/v1" + key })
+	if expected := map[string]int{"/v1": 0, "/v1/a": 1, "/v1/a/B": 2, "/v1/b": 3, "/v1/A": 4}; !reflect.DeepEqual(collect(prefixed), expected) {
+		t.Errorf("expected rekeyed trie %v, got %v", expected, collect(prefixed))
+	}
+	// the source trie is unchanged
+	if expected := map[string]int{"": 0, "/a": 1, "/a/B": 2, "/b": 3, "/A": 4}; !reflect.DeepEqual(collect(trie), expected) {
+		t.Errorf("expected source trie %v, got %v", expected, collect(trie))
 	}
-}
 
-func testTrieWalkError(t *testing.T, trie Trie[any]) {
-	table := map[string]any{
-		"/L1/L2A":        1,
-		"/L1/L2B/L3A":    2,
-		"/L1/L2B/L3B/L4": 42,
-		"/L1/L2B/L3C":    4,
-		"/L1/L2C":        5,
+	// /a wins over /A since it sorts after it
+	lowered := trie.Rekey(strings.ToLower)
+	if expected := map[string]int{"": 0, "/a": 1, "/a/b": 2, "/b": 3}; !reflect.DeepEqual(collect(lowered), expected) {
+		t.Errorf("expected rekeyed trie %v, got %v", expected, collect(lowered))
 	}
+}
 
-	walkerError := errors.New("walker error")
-	walked := 0
+func testTrieWalkPathRemainder(t *testing.T, trie CommonTrie[any]) {
+	for _, key := range []string{"", "/a", "/a/b", "/a/b/c/d", "/這"} {
+		trie.Put(key, key)
+	}
+	// matched key and remainder of each valued node along the path
+	cases := map[string][][2]string{
+		"/a/b/c": {{"", "/a/b/c"}, {"/a", "/b/c"}, {"/a/b", "/c"}},
+		"/a/b":   {{"", "/a/b"}, {"/a", "/b"}, {"/a/b", ""}},
+		"/這/是":   {{"", "/這/是"}, {"/這", "/是"}},
+		"/x":     {{"", "/x"}},
+	}
+	for key, expected := range cases {
+		var walked [][2]string
+		err := trie.WalkPathRemainder(key, func(matchedKey string, value any, remainder string) error {
+			if value != matchedKey {
+				t.Errorf("expected matched key %s to have value %s, got %v", matchedKey, matchedKey, value)
+			}
+			if matchedKey+remainder != key {
+				t.Errorf("expected matched key %q and remainder %q to make up %q", matchedKey, remainder, key)
+			}
+			walked = append(walked, [2]string{matchedKey, remainder})
+			return nil
+		})
+		if err != nil {
+			t.Errorf("expected error nil, got %v", err)
+		}
+		if !reflect.DeepEqual(walked, expected) {
+			t.Errorf("expected walk of %s to visit %v, got %v", key, expected, walked)
+		}
 
-	for key, value := range table {
-		trie.Put(key, value)
+		// WalkPath matches the same keys, including multibyte runes
+		var matched, path []string
+		for _, w := range walked {
+			matched = append(matched, w[0])
+		}
+		trie.WalkPath(key, func(key string, value any) error {
+			path = append(path, key)
+			return nil
+		})
+		if !reflect.DeepEqual(path, matched) {
+			t.Errorf("expected WalkPath of %s to match %q, got %q", key, matched, path)
+		}
 	}
-	walker := func(key string, value interface{}) error {
-		if value == 42 {
+
+	walkerError := errors.New("walker error")
+	var visited int
+	err := trie.WalkPathRemainder("/a/b/c/d", func(matchedKey string, value any, remainder string) error {
+		visited++
+		if matchedKey == "/a" {
 			return walkerError
 		}
-		walked++
 		return nil
-	}
-	if err := trie.Walk(walker); err != walkerError {
-		t.Errorf("expected walker error, got %v", err)
-	}
-	if len(table) == walked {
-		t.Errorf("expected nodes walked < %d, got %d", len(table), walked)
+	})
+	if err != walkerError || visited != 2 {
+		t.Errorf("expected walk to stop with walker error after 2 keys, got %v after %d", err, visited)
 	}
 }
 
@@ -455,3 +3458,45 @@ func testTrieWalkPathError(t *testing.T, trie Trie[any]) {
 		t.Errorf("expected %s, got %s", rootError, err)
 	}
 }
+
+func TestPathTrieWithValidator(t *testing.T) {
+	errTooLong := errors.New("value too long")
+	validator := func(key string, value string) error {
+		if len(value) > 5 {
+			return errTooLong
+		}
+		return nil
+	}
+	trie := NewPathTrie(WithValidator(validator))
+
+	// accepted values
+	if err := trie.PutChecked("/cat", "gizmo"); err != nil {
+		t.Errorf("expected error nil, got %v", err)
+	}
+	if isNew := trie.Put("/dog", "rex"); !isNew {
+		t.Errorf("expected key /dog to be missing")
+	}
+
+	// rejected values
+	if err := trie.PutChecked("/cat", "gideon"); err != errTooLong {
+		t.Errorf("expected validation error, got %v", err)
+	}
+	if isNew := trie.Put("/fish", "nemo the fish"); isNew {
+		t.Errorf("expected key /fish to be rejected")
+	}
+
+	cases := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"/cat", "gizmo", true},
+		{"/dog", "rex", true},
+		{"/fish", "", false},
+	}
+	for _, c := range cases {
+		if value, ok := trie.Get(c.key); ok != c.ok || value != c.value {
+			t.Errorf("expected key %s to have value %q (%t), got %q (%t)", c.key, c.value, c.ok, value, ok)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package trie
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLTrie is a trie whose keys may expire, for use as a cache. It is built
+// on a path trie of values and their expiry times. Expired keys are removed
+// lazily when read, or proactively by an optional background sweeper.
+// TTLTrie is safe for concurrent use.
+type TTLTrie[T any] struct {
+	mu            sync.Mutex
+	trie          PathTrie[ttlEntry[T]]
+	now           func() time.Time
+	sweepInterval time.Duration
+	stop          chan struct{}
+}
+
+// ttlEntry is a value and the time it expires. A zero expiry never expires.
+type ttlEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+func (e ttlEntry[T]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// TTLTrieOption is an optional configuration option for a TTLTrie.
+type TTLTrieOption[T any] func(*TTLTrie[T])
+
+// WithClock sets the function the TTLTrie calls to get the current time.
+// The default is time.Now. Useful to control expiry in tests.
+func WithClock[T any](now func() time.Time) TTLTrieOption[T] {
+	return func(t *TTLTrie[T]) { t.now = now }
+}
+
+// WithSweepInterval starts a background goroutine which deletes expired
+// keys every interval. Call Close to stop it.
+func WithSweepInterval[T any](interval time.Duration) TTLTrieOption[T] {
+	return func(t *TTLTrie[T]) { t.sweepInterval = interval }
+}
+
+// NewTTLTrie allocates and returns a new TTLTrie.
+func NewTTLTrie[T any](opts ...TTLTrieOption[T]) *TTLTrie[T] {
+	t := &TTLTrie[T]{
+		trie: NewPathTrie[ttlEntry[T]](),
+		now:  time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.sweepInterval > 0 {
+		t.stop = make(chan struct{})
+		go t.sweep(t.sweepInterval, t.stop)
+	}
+	return t
+}
+
+// Get returns the value stored at the given key. Returns false if the key
+// is missing or has expired. Expired keys are deleted.
+func (t *TTLTrie[T]) Get(key string) (T, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.trie.Get(key)
+	if !ok {
+		return zeroValueOfT[T](), false
+	}
+	if entry.expired(t.now()) {
+		t.trie.Delete(key)
+		return zeroValueOfT[T](), false
+	}
+	return entry.value, true
+}
+
+// Put inserts the value into the trie at the given key, replacing any
+// existing value and expiry. The key does not expire. It returns true if
+// the put adds a new value, false if it replaces an existing value.
+func (t *TTLTrie[T]) Put(key string, value T) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.put(key, ttlEntry[T]{value: value})
+}
+
+// PutTTL inserts the value into the trie at the given key, replacing any
+// existing value and expiry. The key expires after the ttl elapses. It
+// returns true if the put adds a new value, false if it replaces an
+// existing value.
+func (t *TTLTrie[T]) PutTTL(key string, value T, ttl time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.put(key, ttlEntry[T]{value: value, expires: t.now().Add(ttl)})
+}
+
+// put inserts the entry, treating an expired entry as missing. The caller
+// must hold t.mu.
+func (t *TTLTrie[T]) put(key string, entry ttlEntry[T]) bool {
+	if old, ok := t.trie.Get(key); ok && old.expired(t.now()) {
+		t.trie.Delete(key)
+	}
+	return t.trie.Put(key, entry)
+}
+
+// Delete removes the value associated with the given key. Returns true if
+// an unexpired value was removed.
+func (t *TTLTrie[T]) Delete(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.trie.Get(key)
+	if !ok {
+		return false
+	}
+	t.trie.Delete(key)
+	return !entry.expired(t.now())
+}
+
+// Sweep deletes all expired keys. Returns the number of keys deleted.
+func (t *TTLTrie[T]) Sweep() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	var count int
+	// walkers may delete keys from the trie
+	t.trie.Walk(func(key string, entry ttlEntry[T]) error {
+		if entry.expired(now) {
+			t.trie.Delete(key)
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+// Walk iterates over each unexpired key/value stored in the trie and calls
+// the given walker function with the key and value. If the walker function
+// returns an error, the walk is aborted. The walker must not call methods
+// on the TTLTrie.
+// The traversal is depth first with no guaranteed order.
+func (t *TTLTrie[T]) Walk(walker WalkFunc[T]) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	return t.trie.Walk(func(key string, entry ttlEntry[T]) error {
+		if entry.expired(now) {
+			return nil
+		}
+		return walker(key, entry.value)
+	})
+}
+
+// Close stops the background sweeper, if any.
+func (t *TTLTrie[T]) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+}
+
+func (t *TTLTrie[T]) sweep(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.Sweep()
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package trie
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTTLTrie(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	trie := NewTTLTrie(WithClock[int](clock.Now))
+	if isNew := trie.PutTTL("/cat", 1, time.Minute); !isNew {
+		t.Error("expected key /cat to be missing")
+	}
+	trie.PutTTL("/cat/gideon", 2, time.Hour)
+	trie.Put("/dog", 3)
+
+	clock.Advance(time.Minute - time.Second)
+	if value, ok := trie.Get("/cat"); !ok || value != 1 {
+		t.Errorf("expected key /cat to have value 1, got %v", value)
+	}
+
+	// expired keys miss and are removed on get
+	clock.Advance(time.Second)
+	if value, ok := trie.Get("/cat"); ok {
+		t.Errorf("expected key /cat to be expired, got %v", value)
+	}
+	if _, ok := trie.trie.Get("/cat"); ok {
+		t.Error("expected expired key /cat to be removed")
+	}
+	if value, ok := trie.Get("/cat/gideon"); !ok || value != 2 {
+		t.Errorf("expected key /cat/gideon to have value 2, got %v", value)
+	}
+
+	// putting over an expired key adds a new value
+	trie.PutTTL("/dog/rex", 4, time.Second)
+	clock.Advance(time.Second)
+	if isNew := trie.PutTTL("/dog/rex", 5, time.Second); !isNew {
+		t.Error("expected expired key /dog/rex to be replaced as missing")
+	}
+	if !trie.Delete("/dog/rex") {
+		t.Error("expected key /dog/rex to be deleted")
+	}
+
+	// walk skips expired keys, sweep removes them
+	clock.Advance(2 * time.Hour)
+	walked := make(map[string]int)
+	trie.Walk(func(key string, value int) error {
+		walked[key] = value
+		return nil
+	})
+	if len(walked) != 1 || walked["/dog"] != 3 {
+		t.Errorf("expected only key /dog to be walked, got %v", walked)
+	}
+	if swept := trie.Sweep(); swept != 1 {
+		t.Errorf("expected 1 key swept, got %d", swept)
+	}
+	if value, ok := trie.Get("/dog"); !ok || value != 3 {
+		t.Errorf("expected key /dog to have value 3, got %v", value)
+	}
+}
+
+func TestTTLTrieWithSweepInterval(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	trie := NewTTLTrie(WithClock[int](clock.Now), WithSweepInterval[int](time.Millisecond))
+	defer trie.Close()
+	trie.PutTTL("/cat", 1, time.Minute)
+	trie.Put("/dog", 2)
+
+	clock.Advance(time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		trie.mu.Lock()
+		_, ok := trie.trie.Get("/cat")
+		trie.mu.Unlock()
+		if !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected sweeper to remove expired key /cat")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if value, ok := trie.Get("/dog"); !ok || value != 2 {
+		t.Errorf("expected key /dog to have value 2, got %v", value)
+	}
+	trie.Close()
+	trie.Close() // idempotent
+}
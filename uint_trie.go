@@ -0,0 +1,162 @@
+package trie
+
+import "encoding/binary"
+
+// bitNode is a node of a binary trie keyed by bit strings, most significant
+// bit first. Each node branches on a single bit.
+type bitNode[T any] struct {
+	value    *T
+	children [2]*bitNode[T]
+}
+
+// bitAt returns the i-th bit of key, most significant bit first.
+func bitAt(key []byte, i int) byte {
+	return key[i/8] >> (7 - i%8) & 1
+}
+
+// put inserts the value at the first bits of key. It returns true if the
+// put adds a new value, false if it replaces an existing value.
+func (n *bitNode[T]) put(key []byte, bits int, value T) bool {
+	node := n
+	for i := 0; i < bits; i++ {
+		b := bitAt(key, i)
+		if node.children[b] == nil {
+			node.children[b] = &bitNode[T]{}
+		}
+		node = node.children[b]
+	}
+	isNewVal := node.value == nil
+	node.value = &value
+	return isNewVal
+}
+
+// get returns the value stored at the first bits of key.
+func (n *bitNode[T]) get(key []byte, bits int) (T, bool) {
+	node := n
+	for i := 0; i < bits; i++ {
+		if node = node.children[bitAt(key, i)]; node == nil {
+			return zeroValueOfT[T](), false
+		}
+	}
+	if node.value == nil {
+		return zeroValueOfT[T](), false
+	}
+	return *node.value, true
+}
+
+// delete removes the value stored at the first bits of key, starting from
+// bit i, and removes nodes left without values or children. It returns true
+// if a value was removed.
+func (n *bitNode[T]) delete(key []byte, bits, i int) bool {
+	if i == bits {
+		if n.value == nil {
+			return false
+		}
+		n.value = nil
+		return true
+	}
+	b := bitAt(key, i)
+	child := n.children[b]
+	if child == nil || !child.delete(key, bits, i+1) {
+		return false
+	}
+	if child.value == nil && child.children[0] == nil && child.children[1] == nil {
+		n.children[b] = nil
+	}
+	return true
+}
+
+// longestPrefix returns the value stored at the longest prefix of the first
+// bits of key, along with the length of the prefix in bits.
+func (n *bitNode[T]) longestPrefix(key []byte, bits int) (int, T, bool) {
+	var match *T
+	var matchBits int
+	node := n
+	for i := 0; ; i++ {
+		if node.value != nil {
+			match, matchBits = node.value, i
+		}
+		if i == bits {
+			break
+		}
+		if node = node.children[bitAt(key, i)]; node == nil {
+			break
+		}
+	}
+	if match == nil {
+		return 0, zeroValueOfT[T](), false
+	}
+	return matchBits, *match, true
+}
+
+// UintTrie is a binary trie keyed by uint64 integers, branching on one bit
+// per node from the most significant bit. Besides exact keys, values may be
+// stored at prefixes of the leading bits of keys, like network prefixes,
+// and found with LongestPrefix.
+type UintTrie[T any] struct {
+	root bitNode[T]
+}
+
+// NewUintTrie allocates and returns a new UintTrie.
+func NewUintTrie[T any]() *UintTrie[T] {
+	return &UintTrie[T]{}
+}
+
+// uintKey returns the big endian bytes of the key.
+func uintKey(key uint64) []byte {
+	return binary.BigEndian.AppendUint64(make([]byte, 0, 8), key)
+}
+
+// Get returns the value stored at the given key.
+func (t *UintTrie[T]) Get(key uint64) (T, bool) {
+	return t.root.get(uintKey(key), 64)
+}
+
+// Put inserts the value into the trie at the given key, replacing any
+// existing value. It returns true if the put adds a new value, false if it
+// replaces an existing value.
+func (t *UintTrie[T]) Put(key uint64, value T) bool {
+	return t.root.put(uintKey(key), 64, value)
+}
+
+// Delete removes the value associated with the given key. Returns true if
+// a value was removed.
+func (t *UintTrie[T]) Delete(key uint64) bool {
+	return t.root.delete(uintKey(key), 64, 0)
+}
+
+// PutPrefix inserts the value into the trie at the prefix made up of the
+// leading bits of the given key, replacing any existing value. The bits of
+// the key after the prefix are ignored and a prefix of 64 bits is the key
+// itself. It returns true if the put adds a new value, false if it replaces
+// an existing value or if bits is not between 0 and 64.
+func (t *UintTrie[T]) PutPrefix(key uint64, bits int, value T) bool {
+	if bits < 0 || bits > 64 {
+		return false
+	}
+	return t.root.put(uintKey(key), bits, value)
+}
+
+// DeletePrefix removes the value associated with the prefix made up of the
+// leading bits of the given key. Returns true if a value was removed.
+func (t *UintTrie[T]) DeletePrefix(key uint64, bits int) bool {
+	if bits < 0 || bits > 64 {
+		return false
+	}
+	return t.root.delete(uintKey(key), bits, 0)
+}
+
+// LongestPrefix returns the value stored at the longest prefix of the given
+// key, which may be the key itself, along with the prefix and its length in
+// bits. The bits of the returned prefix after its length are zero. Returns
+// false if no prefix of the key holds a value.
+func (t *UintTrie[T]) LongestPrefix(key uint64) (prefix uint64, bits int, value T, ok bool) {
+	bits, value, ok = t.root.longestPrefix(uintKey(key), 64)
+	if !ok {
+		return 0, 0, value, false
+	}
+	if bits > 0 {
+		prefix = key &^ (1<<(64-bits) - 1)
+	}
+	return prefix, bits, value, true
+}
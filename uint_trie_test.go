@@ -0,0 +1,98 @@
+package trie
+
+import "testing"
+
+func TestUintTrie(t *testing.T) {
+	trie := NewUintTrie[string]()
+	keys := map[uint64]string{
+		0:              "zero",
+		1:              "one",
+		42:             "forty-two",
+		1 << 63:        "high",
+		^uint64(0):     "max",
+		0xdeadbeefcafe: "cafe",
+	}
+	for key, value := range keys {
+		if isNew := trie.Put(key, value); !isNew {
+			t.Errorf("expected key %d to be missing", key)
+		}
+	}
+	for key, expected := range keys {
+		if value, ok := trie.Get(key); !ok || value != expected {
+			t.Errorf("expected key %d to have value %s, got %s", key, expected, value)
+		}
+	}
+	if value, ok := trie.Get(43); ok {
+		t.Errorf("expected key 43 to be missing, got %s", value)
+	}
+	if isNew := trie.Put(42, "answer"); isNew {
+		t.Error("expected key 42 to be replaced")
+	}
+
+	// delete removes the value and emptied nodes
+	if !trie.Delete(42) {
+		t.Error("expected key 42 to be deleted")
+	}
+	if trie.Delete(42) || trie.Delete(43) {
+		t.Error("expected missing keys to not be deleted")
+	}
+	if value, ok := trie.Get(42); ok {
+		t.Errorf("expected key 42 to be missing, got %s", value)
+	}
+	for _, key := range []uint64{0, 1, 1 << 63, ^uint64(0), 0xdeadbeefcafe} {
+		trie.Delete(key)
+	}
+	if trie.root.children != [2]*bitNode[string]{} {
+		t.Error("expected all nodes to be removed")
+	}
+}
+
+func TestUintTrieLongestPrefix(t *testing.T) {
+	trie := NewUintTrie[string]()
+	// like 10.0.0.0/8, 10.1.0.0/16, and host 10.1.2.3 in the high bits
+	trie.PutPrefix(0x0a<<56, 8, "/8")
+	trie.PutPrefix(0x0a01<<48|0xffff, 16, "/16") // bits after the prefix are ignored
+	trie.Put(0x0a010203<<32, "host")
+	if trie.PutPrefix(0, 65, "invalid") {
+		t.Error("expected prefix of 65 bits to be invalid")
+	}
+
+	cases := []struct {
+		key    uint64
+		prefix uint64
+		bits   int
+		value  string
+		ok     bool
+	}{
+		{0x0a010203 << 32, 0x0a010203 << 32, 64, "host", true},
+		{0x0a010204 << 32, 0x0a01 << 48, 16, "/16", true},
+		{0x0a020000 << 32, 0x0a << 56, 8, "/8", true},
+		{0x0b << 56, 0, 0, "", false},
+	}
+	for _, c := range cases {
+		prefix, bits, value, ok := trie.LongestPrefix(c.key)
+		if prefix != c.prefix || bits != c.bits || value != c.value || ok != c.ok {
+			t.Errorf("expected key %#x to have longest prefix (%#x, %d, %q, %t), got (%#x, %d, %q, %t)", c.key, c.prefix, c.bits, c.value, c.ok, prefix, bits, value, ok)
+		}
+	}
+
+	// a zero length prefix matches every key
+	trie.PutPrefix(0, 0, "default")
+	if prefix, bits, value, ok := trie.LongestPrefix(0x0b << 56); prefix != 0 || bits != 0 || value != "default" || !ok {
+		t.Errorf("expected default route, got (%#x, %d, %q, %t)", prefix, bits, value, ok)
+	}
+
+	// prefixes and keys are distinct
+	if _, ok := trie.Get(0x0a << 56); ok {
+		t.Error("expected prefix /8 to not be a key")
+	}
+	if !trie.DeletePrefix(0x0a01<<48, 16) {
+		t.Error("expected prefix /16 to be deleted")
+	}
+	if _, bits, value, _ := trie.LongestPrefix(0x0a010204 << 32); bits != 8 || value != "/8" {
+		t.Errorf("expected key to fall back to prefix /8, got %q", value)
+	}
+	if _, _, value, _ := trie.LongestPrefix(0x0a010203 << 32); value != "host" {
+		t.Errorf("expected host to remain after deleting its prefix, got %q", value)
+	}
+}
@@ -0,0 +1,50 @@
+package trie
+
+import "sort"
+
+// WithVersions records a version for each value put into the path trie, for
+// change data capture. A trie-wide version counter is incremented by each
+// Put, PutChecked, PutPath, PutRef, or loaded value, and the value is
+// stamped with the new version. Use Version and ChangedSince to find keys
+// put after a point in time. Tracking costs an extra map entry per stored
+// value.
+func WithVersions[T any]() PathTrieOption[T] {
+	return func(trie *pathTrie[T]) {
+		trie.config.versions = map[*pathTrie[T]]uint64{}
+	}
+}
+
+// Version returns the current version of the trie, which is the version of
+// the most recently put value. Returns 0 if no values have been put or the
+// trie was not created WithVersions.
+func (trie *pathTrie[T]) Version() uint64 {
+	return trie.config.version
+}
+
+// ChangedSince returns the keys whose values were put after the given
+// version, in the order they were put. Deleted keys and values modified in
+// place (e.g. by WalkMutate) are not reported. Returns nil if the trie was
+// not created WithVersions.
+func (trie *pathTrie[T]) ChangedSince(version uint64) []string {
+	if trie.config.versions == nil {
+		return nil
+	}
+	type entry struct {
+		key     string
+		version uint64
+	}
+	var entries []entry
+	trie.walkNodes("", func(key string, node *pathTrie[T]) {
+		if v := trie.config.versions[node]; v > version {
+			entries = append(entries, entry{key: key, version: v})
+		}
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].version < entries[j].version
+	})
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+	}
+	return keys
+}
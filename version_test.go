@@ -0,0 +1,43 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathTrieWithVersions(t *testing.T) {
+	trie := NewPathTrie(WithVersions[int]())
+	if version := trie.Version(); version != 0 {
+		t.Errorf("expected version 0, got %d", version)
+	}
+	trie.Put("/cat", 1)
+	trie.Put("/cat/gideon", 2)
+	trie.Put("/dog", 3)
+	cutoff := trie.Version()
+	if cutoff != 3 {
+		t.Errorf("expected version 3, got %d", cutoff)
+	}
+
+	// replacing a value bumps its version, deleting forgets it
+	trie.Put("/fish", 4)
+	trie.Put("/cat", 5)
+	trie.PutPath([]string{"/dog", "/rex"}, 6)
+	trie.Delete("/fish")
+
+	if keys := trie.ChangedSince(cutoff); !reflect.DeepEqual(keys, []string{"/cat", "/dog/rex"}) {
+		t.Errorf("expected keys [/cat /dog/rex] changed since version %d, got %v", cutoff, keys)
+	}
+	if keys := trie.ChangedSince(0); !reflect.DeepEqual(keys, []string{"/cat/gideon", "/dog", "/cat", "/dog/rex"}) {
+		t.Errorf("expected all keys in put order, got %v", keys)
+	}
+	if keys := trie.ChangedSince(trie.Version()); len(keys) != 0 {
+		t.Errorf("expected no keys changed since the current version, got %v", keys)
+	}
+	if tracked := len(trie.(*pathTrie[int]).config.versions); tracked != 4 {
+		t.Errorf("expected 4 tracked values, got %d", tracked)
+	}
+
+	if keys := NewPathTrie[int]().ChangedSince(0); keys != nil {
+		t.Errorf("expected nil without versions, got %v", keys)
+	}
+}
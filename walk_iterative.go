@@ -0,0 +1,44 @@
+package trie
+
+// WithIterativeWalk makes Walk traverse the trie with an explicit stack
+// rather than recursion, so walking very deep tries does not grow the
+// goroutine stack. Walks visit keys in the same order either way. See the
+// Walk benchmarks to compare them for a trie's shape.
+func WithIterativeWalk[T any]() PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.iterativeWalk = true }
+}
+
+// walkIterative walks the subtree like walk, using a stack of the nodes
+// left to visit.
+func (trie *pathTrie[T]) walkIterative(key string, walker WalkFunc[T]) error {
+	type pending struct {
+		key  string
+		node *pathTrie[T]
+	}
+	stack := []pending{{key: key, node: trie}}
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node := next.node
+		// push children before calling the walker so a walker which puts
+		// or deletes keys does not modify the children being iterated, in
+		// reverse so they are visited in order
+		if node.config.walkSeed != nil {
+			children := node.snapshotChildren()
+			node.shuffleChildren(next.key, children)
+			for i := len(children) - 1; i >= 0; i-- {
+				stack = append(stack, pending{key: next.key + children[i].part, node: children[i].node})
+			}
+		} else {
+			for part, child := range node.childNodes() {
+				stack = append(stack, pending{key: next.key + part, node: child})
+			}
+		}
+		if node.value != nil {
+			if err := walker(next.key, node.readValue(*node.value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package trie
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"sort"
+)
+
+// WithWalkSeed makes Walk visit the children of each node in a shuffled
+// order derived from the seed and the node's key, rather than Go's
+// randomized map order. Walks of tries holding the same keys with the same
+// seed visit keys in the same order, across runs, without the order being
+// sorted.
+func WithWalkSeed[T any](seed int64) PathTrieOption[T] {
+	return func(trie *pathTrie[T]) { trie.config.walkSeed = &seed }
+}
+
+// shuffleChildren sorts the children of the node at key, then shuffles them
+// deterministically using the walk seed and the key.
+func (trie *pathTrie[T]) shuffleChildren(key string, children []nodeStr[T]) {
+	sort.Slice(children, func(i, j int) bool { return children[i].part < children[j].part })
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	rng := rand.New(rand.NewPCG(uint64(*trie.config.walkSeed), h.Sum64()))
+	rng.Shuffle(len(children), func(i, j int) {
+		children[i], children[j] = children[j], children[i]
+	})
+}